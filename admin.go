@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireAdmin rejects any request whose user is not flagged as an admin
+func requireAdmin(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		c.Abort()
+		return
+	}
+
+	admin, err := isAdmin(c, userUuid)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get user")
+		c.Abort()
+		return
+	}
+
+	if !admin {
+		sendError(c, http.StatusForbidden, fmt.Errorf("user %s is not an admin", userUuid), "Admin access required")
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
+func registerAdminRoutes(router *gin.RouterGroup) {
+	router.Use(requireAdmin)
+	router.POST("/seedIngredients", _handleSeedIngredients)
+	router.GET("/ingredientPromotions", _handleGetIngredientPromotions)
+	router.POST("/reviewIngredientPromotion", _handleReviewIngredientPromotion)
+	router.POST("/newIngredientCategory", _handleNewIngredientCategory)
+}
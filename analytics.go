@@ -0,0 +1,340 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"pantree/api/db"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+var analyticsPeriods = map[string]bool{"week": true, "month": true}
+
+// reads the optional from/to query params (ms since epoch), responding with
+// 400 and returning false when one is invalid
+func _parseDateRange(c *gin.Context) (*time.Time, *time.Time, bool) {
+	var bounds [2]*time.Time
+	for i, key := range []string{"from", "to"} {
+		param := c.Query(key)
+		if param == "" {
+			continue
+		}
+
+		ms, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, err, fmt.Sprintf("%s must be a timestamp in ms", key))
+			return nil, nil, false
+		}
+
+		bounds[i] = msToTime(&ms)
+	}
+
+	return bounds[0], bounds[1], true
+}
+
+func _parsePeriod(c *gin.Context) (string, bool) {
+	period := c.DefaultQuery("period", "month")
+	if !analyticsPeriods[period] {
+		sendError(c, http.StatusBadRequest, fmt.Errorf("unknown period %q", period), "period must be week or month")
+		return "", false
+	}
+
+	return period, true
+}
+
+/**
+ * /spend
+ */
+func _handleGetSpendByPeriod(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	period, ok := _parsePeriod(c)
+	if !ok {
+		return
+	}
+
+	from, to, ok := _parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	spend, err := queries.GetSpendByPeriod(c, db.GetSpendByPeriodParams{
+		Period: period,
+		UserID: &userUuid,
+		From:   from,
+		To:     to,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get spending")
+		return
+	}
+
+	if spend == nil {
+		spend = []db.GetSpendByPeriodRow{}
+	}
+
+	c.JSON(http.StatusOK, spend)
+}
+
+/**
+ * /spendByType
+ */
+func _handleGetSpendByIngredientType(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	from, to, ok := _parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	spend, err := queries.GetSpendByIngredientType(c, db.GetSpendByIngredientTypeParams{
+		UserID: &userUuid,
+		From:   from,
+		To:     to,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get spending")
+		return
+	}
+
+	if spend == nil {
+		spend = []db.GetSpendByIngredientTypeRow{}
+	}
+
+	c.JSON(http.StatusOK, spend)
+}
+
+/**
+ * /spendByIngredient
+ */
+func _handleGetSpendByIngredient(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	from, to, ok := _parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	spend, err := queries.GetSpendByIngredient(c, db.GetSpendByIngredientParams{
+		UserID: &userUuid,
+		From:   from,
+		To:     to,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get spending")
+		return
+	}
+
+	if spend == nil {
+		spend = []db.GetSpendByIngredientRow{}
+	}
+
+	c.JSON(http.StatusOK, spend)
+}
+
+/**
+ * /unitPrices
+ */
+func _handleGetUnitPriceHistory(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	ingredientId, err := uuid.Parse(c.Query("ingredientId"))
+	if err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid ingredient id")
+		return
+	}
+
+	period, ok := _parsePeriod(c)
+	if !ok {
+		return
+	}
+
+	from, to, ok := _parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	prices, err := queries.GetUnitPriceHistory(c, db.GetUnitPriceHistoryParams{
+		Period:       period,
+		UserID:       &userUuid,
+		IngredientID: &ingredientId,
+		From:         from,
+		To:           to,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get unit prices")
+		return
+	}
+
+	if prices == nil {
+		prices = []db.GetUnitPriceHistoryRow{}
+	}
+
+	c.JSON(http.StatusOK, prices)
+}
+
+/**
+ * /discarded
+ */
+func _handleGetDiscardedCost(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	from, to, ok := _parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	discarded, err := queries.GetDiscardedCost(c, db.GetDiscardedCostParams{
+		UserID: &userUuid,
+		From:   from,
+		To:     to,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get discarded items")
+		return
+	}
+
+	if discarded == nil {
+		discarded = []db.GetDiscardedCostRow{}
+	}
+
+	c.JSON(http.StatusOK, discarded)
+}
+
+/**
+ * /wasteByIngredient
+ */
+func _handleGetWasteByIngredient(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	from, to, ok := _parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	rows, err := queries.GetWasteByIngredient(c, db.GetWasteByIngredientParams{
+		UserID: &userUuid,
+		From:   from,
+		To:     to,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get waste by ingredient")
+		return
+	}
+
+	if rows == nil {
+		rows = []db.GetWasteByIngredientRow{}
+	}
+
+	c.JSON(http.StatusOK, rows)
+}
+
+/**
+ * /wasteByCategory
+ */
+func _handleGetWasteByCategory(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	from, to, ok := _parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	rows, err := queries.GetWasteByCategory(c, db.GetWasteByCategoryParams{
+		UserID: &userUuid,
+		From:   from,
+		To:     to,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get waste by category")
+		return
+	}
+
+	if rows == nil {
+		rows = []db.GetWasteByCategoryRow{}
+	}
+
+	c.JSON(http.StatusOK, rows)
+}
+
+/**
+ * /wasteByMonth
+ */
+func _handleGetDisposalsByMonth(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	from, to, ok := _parseDateRange(c)
+	if !ok {
+		return
+	}
+
+	rows, err := queries.GetDisposalsByMonth(c, db.GetDisposalsByMonthParams{
+		UserID: &userUuid,
+		From:   from,
+		To:     to,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get disposals by month")
+		return
+	}
+
+	if rows == nil {
+		rows = []db.GetDisposalsByMonthRow{}
+	}
+
+	c.JSON(http.StatusOK, rows)
+}
+
+func registerAnalyticsRoutes(router *gin.RouterGroup) {
+	router.GET("/spend", _handleGetSpendByPeriod)
+	router.GET("/spendByType", _handleGetSpendByIngredientType)
+	router.GET("/spendByIngredient", _handleGetSpendByIngredient)
+	router.GET("/unitPrices", _handleGetUnitPriceHistory)
+	router.GET("/discarded", _handleGetDiscardedCost)
+	router.GET("/wasteByIngredient", _handleGetWasteByIngredient)
+	router.GET("/wasteByCategory", _handleGetWasteByCategory)
+	router.GET("/wasteByMonth", _handleGetDisposalsByMonth)
+}
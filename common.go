@@ -1,6 +1,15 @@
 package main
 
-import "github.com/jackc/pgx/v5/pgtype"
+import (
+	"errors"
+	"pantree/api/db"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
 
 func getPgtypeText(s string) pgtype.Text {
 	return pgtype.Text{
@@ -9,8 +18,88 @@ func getPgtypeText(s string) pgtype.Text {
 	}
 }
 
+// optionalText is null for missing or blank strings
+func optionalText(s *string) pgtype.Text {
+	if s == nil || strings.TrimSpace(*s) == "" {
+		return pgtype.Text{}
+	}
+	return getPgtypeText(strings.TrimSpace(*s))
+}
+
+// normalizeTags trims, lowercases and dedupes tags, dropping empty ones
+func normalizeTags(tags []string) []string {
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(normalizeIngredientName(tag))
+		if tag != "" && !slices.Contains(normalized, tag) {
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized
+}
+
 func getPgtypeNumeric(s string) (pgtype.Numeric, error) {
 	var numeric pgtype.Numeric
 	err := numeric.Scan(s)
 	return numeric, err
 }
+
+// converts the unix millisecond timestamps clients send into a time
+func msToTime(ms *float64) *time.Time {
+	if ms == nil {
+		return nil
+	}
+	t := time.UnixMilli(int64(*ms)).UTC()
+	return &t
+}
+
+// isUniqueViolation reports whether err came from a unique constraint or index
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func isValidUnitType(unit db.UnitType) bool {
+	switch unit {
+	case db.UnitTypeCountQtr, db.UnitTypeVolumeMl, db.UnitTypeMassG:
+		return true
+	}
+	return false
+}
+
+func isValidLocType(loc db.LocType) bool {
+	switch loc {
+	case db.LocTypePantry, db.LocTypeFridge, db.LocTypeFreezer:
+		return true
+	}
+	return false
+}
+
+func isValidGrocType(grocType db.GrocType) bool {
+	switch grocType {
+	case db.GrocTypeMeatSeafood,
+		db.GrocTypeProduce,
+		db.GrocTypeDairyEggs,
+		db.GrocTypePrepared,
+		db.GrocTypeEssentials,
+		db.GrocTypeBakery,
+		db.GrocTypeSnacks,
+		db.GrocTypeFrozen,
+		db.GrocTypeBeverages,
+		db.GrocTypeDesserts,
+		db.GrocTypeAlcohol:
+		return true
+	}
+	return false
+}
+
+func isValidDisposalOutcome(outcome db.DisposalOutcome) bool {
+	switch outcome {
+	case db.DisposalOutcomeConsumed,
+		db.DisposalOutcomeDiscardedExpired,
+		db.DisposalOutcomeDiscardedSpoiled,
+		db.DisposalOutcomeDonated:
+		return true
+	}
+	return false
+}
@@ -12,6 +12,66 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countIngredientReferences = `-- name: CountIngredientReferences :one
+SELECT
+  CAST(
+    (
+      SELECT
+        COUNT(*)
+      FROM
+        RecipeIngredients ri
+        JOIN Recipes r ON ri.recipe_id = r.id
+      WHERE
+        ri.ingredient_id = $1::uuid
+        AND r.creator_id IS DISTINCT FROM $2::uuid
+    ) + (
+      SELECT
+        COUNT(*)
+      FROM
+        UserItemEntries ui
+      WHERE
+        ui.ingredient_id = $1::uuid
+        AND ui.user_id IS DISTINCT FROM $2::uuid
+    ) AS BIGINT
+  ) AS other_references,
+  CAST(
+    (
+      SELECT
+        COUNT(*)
+      FROM
+        RecipeIngredients ri
+      WHERE
+        ri.ingredient_id = $1::uuid
+    ) + (
+      SELECT
+        COUNT(*)
+      FROM
+        UserItemEntries ui
+      WHERE
+        ui.ingredient_id = $1::uuid
+    ) AS BIGINT
+  ) AS total_references
+`
+
+type CountIngredientReferencesParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"userId"`
+}
+
+type CountIngredientReferencesRow struct {
+	OtherReferences int64 `json:"otherReferences"`
+	TotalReferences int64 `json:"totalReferences"`
+}
+
+// counts recipes and pantry entries pointing at an ingredient, split by
+// whether they belong to someone other than the given user
+func (q *Queries) CountIngredientReferences(ctx context.Context, arg CountIngredientReferencesParams) (CountIngredientReferencesRow, error) {
+	row := q.db.QueryRow(ctx, countIngredientReferences, arg.ID, arg.UserID)
+	var i CountIngredientReferencesRow
+	err := row.Scan(&i.OtherReferences, &i.TotalReferences)
+	return i, err
+}
+
 const createIngredient = `-- name: CreateIngredient :one
 INSERT INTO
   Ingredients (
@@ -20,7 +80,10 @@ INSERT INTO
     unit,
     storage_loc,
     ingredient_type,
-    image_path
+    image_path,
+    visibility,
+    household_id,
+    opened_shelf_life_days
   )
 VALUES
   (
@@ -29,19 +92,25 @@ VALUES
     $3,
     $4,
     $5,
-    $6
+    $6,
+    $7,
+    $8,
+    $9
   )
 RETURNING
-  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified
+  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified, deleted, aliases, shelf_life_days, opened_shelf_life_days, visibility, household_id, category_id
 `
 
 type CreateIngredientParams struct {
-	CreatorID      *uuid.UUID  `json:"creatorId"`
-	Name           string      `json:"name"`
-	Unit           UnitType    `json:"unit"`
-	StorageLoc     LocType     `json:"storageLoc"`
-	IngredientType GrocType    `json:"ingredientType"`
-	ImagePath      pgtype.Text `json:"imagePath"`
+	CreatorID           *uuid.UUID     `json:"creatorId"`
+	Name                string         `json:"name"`
+	Unit                UnitType       `json:"unit"`
+	StorageLoc          LocType        `json:"storageLoc"`
+	IngredientType      GrocType       `json:"ingredientType"`
+	ImagePath           pgtype.Text    `json:"imagePath"`
+	Visibility          VisibilityType `json:"visibility"`
+	HouseholdID         *uuid.UUID     `json:"householdId"`
+	OpenedShelfLifeDays pgtype.Int4    `json:"openedShelfLifeDays"`
 }
 
 // WHERE
@@ -57,6 +126,9 @@ func (q *Queries) CreateIngredient(ctx context.Context, arg CreateIngredientPara
 		arg.StorageLoc,
 		arg.IngredientType,
 		arg.ImagePath,
+		arg.Visibility,
+		arg.HouseholdID,
+		arg.OpenedShelfLifeDays,
 	)
 	var i Ingredient
 	err := row.Scan(
@@ -68,30 +140,112 @@ func (q *Queries) CreateIngredient(ctx context.Context, arg CreateIngredientPara
 		&i.IngredientType,
 		&i.ImagePath,
 		&i.LastModified,
+		&i.Deleted,
+		&i.Aliases,
+		&i.ShelfLifeDays,
+		&i.OpenedShelfLifeDays,
+		&i.Visibility,
+		&i.HouseholdID,
+		&i.CategoryID,
+	)
+	return i, err
+}
+
+const createIngredientPromotion = `-- name: CreateIngredientPromotion :one
+INSERT INTO
+  IngredientPromotions (ingredient_id, requester_id, note)
+VALUES
+  (
+    $1,
+    $2,
+    $3
+  )
+RETURNING
+  id, ingredient_id, requester_id, reviewer_id, status, note, date_created, last_modified
+`
+
+type CreateIngredientPromotionParams struct {
+	IngredientID uuid.UUID   `json:"ingredientId"`
+	RequesterID  *uuid.UUID  `json:"requesterId"`
+	Note         pgtype.Text `json:"note"`
+}
+
+func (q *Queries) CreateIngredientPromotion(ctx context.Context, arg CreateIngredientPromotionParams) (Ingredientpromotion, error) {
+	row := q.db.QueryRow(ctx, createIngredientPromotion, arg.IngredientID, arg.RequesterID, arg.Note)
+	var i Ingredientpromotion
+	err := row.Scan(
+		&i.ID,
+		&i.IngredientID,
+		&i.RequesterID,
+		&i.ReviewerID,
+		&i.Status,
+		&i.Note,
+		&i.DateCreated,
+		&i.LastModified,
 	)
 	return i, err
 }
 
-const deleteIngredient = `-- name: DeleteIngredient :exec
-DELETE FROM Ingredients
+const getIngredient = `-- name: GetIngredient :one
+SELECT
+  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified, deleted, aliases, shelf_life_days, opened_shelf_life_days, visibility, household_id, category_id
+FROM
+  Ingredients
 WHERE
   id = $1
 `
 
-func (q *Queries) DeleteIngredient(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, deleteIngredient, id)
-	return err
+func (q *Queries) GetIngredient(ctx context.Context, id uuid.UUID) (Ingredient, error) {
+	row := q.db.QueryRow(ctx, getIngredient, id)
+	var i Ingredient
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.Name,
+		&i.Unit,
+		&i.StorageLoc,
+		&i.IngredientType,
+		&i.ImagePath,
+		&i.LastModified,
+		&i.Deleted,
+		&i.Aliases,
+		&i.ShelfLifeDays,
+		&i.OpenedShelfLifeDays,
+		&i.Visibility,
+		&i.HouseholdID,
+		&i.CategoryID,
+	)
+	return i, err
 }
 
 const getIngredients = `-- name: GetIngredients :many
 SELECT
-  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified
+  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified, deleted, aliases, shelf_life_days, opened_shelf_life_days, visibility, household_id, category_id
 FROM
   Ingredients
+WHERE
+  deleted = false
+  AND (
+    visibility = 'global'
+    OR creator_id = $1::uuid
+    OR (
+      visibility = 'household'
+      AND household_id IN (
+        SELECT
+          household_id
+        FROM
+          HouseholdMembers
+        WHERE
+          user_id = $1::uuid
+      )
+    )
+  )
 `
 
-func (q *Queries) GetIngredients(ctx context.Context) ([]Ingredient, error) {
-	rows, err := q.db.Query(ctx, getIngredients)
+// only ingredients the user may see: the global catalog, their own and
+// ones shared with a household they belong to
+func (q *Queries) GetIngredients(ctx context.Context, userID uuid.UUID) ([]Ingredient, error) {
+	rows, err := q.db.Query(ctx, getIngredients, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -108,6 +262,13 @@ func (q *Queries) GetIngredients(ctx context.Context) ([]Ingredient, error) {
 			&i.IngredientType,
 			&i.ImagePath,
 			&i.LastModified,
+			&i.Deleted,
+			&i.Aliases,
+			&i.ShelfLifeDays,
+			&i.OpenedShelfLifeDays,
+			&i.Visibility,
+			&i.HouseholdID,
+			&i.CategoryID,
 		); err != nil {
 			return nil, err
 		}
@@ -121,7 +282,7 @@ func (q *Queries) GetIngredients(ctx context.Context) ([]Ingredient, error) {
 
 const getIngredientsByIds = `-- name: GetIngredientsByIds :many
 SELECT
-  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified
+  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified, deleted, aliases, shelf_life_days, opened_shelf_life_days, visibility, household_id, category_id
 FROM
   Ingredients
 WHERE
@@ -146,6 +307,53 @@ func (q *Queries) GetIngredientsByIds(ctx context.Context, ids []uuid.UUID) ([]I
 			&i.IngredientType,
 			&i.ImagePath,
 			&i.LastModified,
+			&i.Deleted,
+			&i.Aliases,
+			&i.ShelfLifeDays,
+			&i.OpenedShelfLifeDays,
+			&i.Visibility,
+			&i.HouseholdID,
+			&i.CategoryID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPendingIngredientPromotions = `-- name: GetPendingIngredientPromotions :many
+SELECT
+  id, ingredient_id, requester_id, reviewer_id, status, note, date_created, last_modified
+FROM
+  IngredientPromotions
+WHERE
+  status = 'pending'
+ORDER BY
+  date_created
+`
+
+func (q *Queries) GetPendingIngredientPromotions(ctx context.Context) ([]Ingredientpromotion, error) {
+	rows, err := q.db.Query(ctx, getPendingIngredientPromotions)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Ingredientpromotion
+	for rows.Next() {
+		var i Ingredientpromotion
+		if err := rows.Scan(
+			&i.ID,
+			&i.IngredientID,
+			&i.RequesterID,
+			&i.ReviewerID,
+			&i.Status,
+			&i.Note,
+			&i.DateCreated,
+			&i.LastModified,
 		); err != nil {
 			return nil, err
 		}
@@ -157,17 +365,194 @@ func (q *Queries) GetIngredientsByIds(ctx context.Context, ids []uuid.UUID) ([]I
 	return items, nil
 }
 
+const getVisibleIngredient = `-- name: GetVisibleIngredient :one
+SELECT
+  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified, deleted, aliases, shelf_life_days, opened_shelf_life_days, visibility, household_id, category_id
+FROM
+  Ingredients
+WHERE
+  id = $1
+  AND deleted = false
+  AND (
+    visibility = 'global'
+    OR creator_id = $2::uuid
+    OR (
+      visibility = 'household'
+      AND household_id IN (
+        SELECT
+          household_id
+        FROM
+          HouseholdMembers
+        WHERE
+          user_id = $2::uuid
+      )
+    )
+  )
+`
+
+type GetVisibleIngredientParams struct {
+	ID     uuid.UUID `json:"id"`
+	UserID uuid.UUID `json:"userId"`
+}
+
+// same rule as GetIngredients, for anything that lets a user act on the
+// ingredient rather than just read an entry that already points at it
+func (q *Queries) GetVisibleIngredient(ctx context.Context, arg GetVisibleIngredientParams) (Ingredient, error) {
+	row := q.db.QueryRow(ctx, getVisibleIngredient, arg.ID, arg.UserID)
+	var i Ingredient
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.Name,
+		&i.Unit,
+		&i.StorageLoc,
+		&i.IngredientType,
+		&i.ImagePath,
+		&i.LastModified,
+		&i.Deleted,
+		&i.Aliases,
+		&i.ShelfLifeDays,
+		&i.OpenedShelfLifeDays,
+		&i.Visibility,
+		&i.HouseholdID,
+		&i.CategoryID,
+	)
+	return i, err
+}
+
+const getVisibleIngredientsByIds = `-- name: GetVisibleIngredientsByIds :many
+SELECT
+  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified, deleted, aliases, shelf_life_days, opened_shelf_life_days, visibility, household_id, category_id
+FROM
+  Ingredients
+WHERE
+  id = ANY($1::uuid[])
+  AND (
+    visibility = 'global'
+    OR creator_id = $2::uuid
+    OR (
+      visibility = 'household'
+      AND household_id IN (
+        SELECT
+          household_id
+        FROM
+          HouseholdMembers
+        WHERE
+          user_id = $2::uuid
+      )
+    )
+  )
+`
+
+type GetVisibleIngredientsByIdsParams struct {
+	Ids    []uuid.UUID `json:"ids"`
+	UserID uuid.UUID   `json:"userId"`
+}
+
+func (q *Queries) GetVisibleIngredientsByIds(ctx context.Context, arg GetVisibleIngredientsByIdsParams) ([]Ingredient, error) {
+	rows, err := q.db.Query(ctx, getVisibleIngredientsByIds, arg.Ids, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Ingredient
+	for rows.Next() {
+		var i Ingredient
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatorID,
+			&i.Name,
+			&i.Unit,
+			&i.StorageLoc,
+			&i.IngredientType,
+			&i.ImagePath,
+			&i.LastModified,
+			&i.Deleted,
+			&i.Aliases,
+			&i.ShelfLifeDays,
+			&i.OpenedShelfLifeDays,
+			&i.Visibility,
+			&i.HouseholdID,
+			&i.CategoryID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const reviewIngredientPromotion = `-- name: ReviewIngredientPromotion :one
+UPDATE IngredientPromotions
+SET
+  status = $1,
+  reviewer_id = $2,
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = $3
+  AND status = 'pending'
+RETURNING
+  id, ingredient_id, requester_id, reviewer_id, status, note, date_created, last_modified
+`
+
+type ReviewIngredientPromotionParams struct {
+	Status     PromotionStatus `json:"status"`
+	ReviewerID *uuid.UUID      `json:"reviewerId"`
+	ID         uuid.UUID       `json:"id"`
+}
+
+// only pending requests can be reviewed, so a second review returns no rows
+func (q *Queries) ReviewIngredientPromotion(ctx context.Context, arg ReviewIngredientPromotionParams) (Ingredientpromotion, error) {
+	row := q.db.QueryRow(ctx, reviewIngredientPromotion, arg.Status, arg.ReviewerID, arg.ID)
+	var i Ingredientpromotion
+	err := row.Scan(
+		&i.ID,
+		&i.IngredientID,
+		&i.RequesterID,
+		&i.ReviewerID,
+		&i.Status,
+		&i.Note,
+		&i.DateCreated,
+		&i.LastModified,
+	)
+	return i, err
+}
+
 const searchIngredients = `-- name: SearchIngredients :many
 SELECT
-  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified
+  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified, deleted, aliases, shelf_life_days, opened_shelf_life_days, visibility, household_id, category_id
 FROM
   Ingredients
 WHERE
-  name ILIKE '%' || $1 || '%'
+  deleted = false
+  AND name ILIKE '%' || $1 || '%'
+  AND (
+    visibility = 'global'
+    OR creator_id = $2::uuid
+    OR (
+      visibility = 'household'
+      AND household_id IN (
+        SELECT
+          household_id
+        FROM
+          HouseholdMembers
+        WHERE
+          user_id = $2::uuid
+      )
+    )
+  )
 `
 
-func (q *Queries) SearchIngredients(ctx context.Context, name pgtype.Text) ([]Ingredient, error) {
-	rows, err := q.db.Query(ctx, searchIngredients, name)
+type SearchIngredientsParams struct {
+	Name   pgtype.Text `json:"name"`
+	UserID uuid.UUID   `json:"userId"`
+}
+
+func (q *Queries) SearchIngredients(ctx context.Context, arg SearchIngredientsParams) ([]Ingredient, error) {
+	rows, err := q.db.Query(ctx, searchIngredients, arg.Name, arg.UserID)
 	if err != nil {
 		return nil, err
 	}
@@ -184,6 +569,13 @@ func (q *Queries) SearchIngredients(ctx context.Context, name pgtype.Text) ([]In
 			&i.IngredientType,
 			&i.ImagePath,
 			&i.LastModified,
+			&i.Deleted,
+			&i.Aliases,
+			&i.ShelfLifeDays,
+			&i.OpenedShelfLifeDays,
+			&i.Visibility,
+			&i.HouseholdID,
+			&i.CategoryID,
 		); err != nil {
 			return nil, err
 		}
@@ -194,3 +586,231 @@ func (q *Queries) SearchIngredients(ctx context.Context, name pgtype.Text) ([]In
 	}
 	return items, nil
 }
+
+const setIngredientVisibility = `-- name: SetIngredientVisibility :one
+UPDATE Ingredients
+SET
+  visibility = $1,
+  household_id = $2,
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = $3
+RETURNING
+  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified, deleted, aliases, shelf_life_days, opened_shelf_life_days, visibility, household_id, category_id
+`
+
+type SetIngredientVisibilityParams struct {
+	Visibility  VisibilityType `json:"visibility"`
+	HouseholdID *uuid.UUID     `json:"householdId"`
+	ID          uuid.UUID      `json:"id"`
+}
+
+func (q *Queries) SetIngredientVisibility(ctx context.Context, arg SetIngredientVisibilityParams) (Ingredient, error) {
+	row := q.db.QueryRow(ctx, setIngredientVisibility, arg.Visibility, arg.HouseholdID, arg.ID)
+	var i Ingredient
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.Name,
+		&i.Unit,
+		&i.StorageLoc,
+		&i.IngredientType,
+		&i.ImagePath,
+		&i.LastModified,
+		&i.Deleted,
+		&i.Aliases,
+		&i.ShelfLifeDays,
+		&i.OpenedShelfLifeDays,
+		&i.Visibility,
+		&i.HouseholdID,
+		&i.CategoryID,
+	)
+	return i, err
+}
+
+const softDeleteIngredient = `-- name: SoftDeleteIngredient :one
+UPDATE Ingredients
+SET
+  deleted = true,
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = $1
+  AND deleted = false
+RETURNING
+  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified, deleted, aliases, shelf_life_days, opened_shelf_life_days, visibility, household_id, category_id
+`
+
+// keeps the row so existing recipes and pantry entries stay valid
+func (q *Queries) SoftDeleteIngredient(ctx context.Context, id uuid.UUID) (Ingredient, error) {
+	row := q.db.QueryRow(ctx, softDeleteIngredient, id)
+	var i Ingredient
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.Name,
+		&i.Unit,
+		&i.StorageLoc,
+		&i.IngredientType,
+		&i.ImagePath,
+		&i.LastModified,
+		&i.Deleted,
+		&i.Aliases,
+		&i.ShelfLifeDays,
+		&i.OpenedShelfLifeDays,
+		&i.Visibility,
+		&i.HouseholdID,
+		&i.CategoryID,
+	)
+	return i, err
+}
+
+const updateIngredient = `-- name: UpdateIngredient :one
+UPDATE Ingredients
+SET
+  name = COALESCE($1, name),
+  unit = COALESCE($2::unit_type, unit),
+  storage_loc = COALESCE($3::loc_type, storage_loc),
+  ingredient_type = COALESCE(
+    $4::groc_type,
+    ingredient_type
+  ),
+  image_path = COALESCE($5, image_path),
+  category_id = COALESCE($6, category_id),
+  opened_shelf_life_days = COALESCE(
+    $7,
+    opened_shelf_life_days
+  ),
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = $8
+  AND deleted = false
+RETURNING
+  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified, deleted, aliases, shelf_life_days, opened_shelf_life_days, visibility, household_id, category_id
+`
+
+type UpdateIngredientParams struct {
+	Name                pgtype.Text  `json:"name"`
+	Unit                NullUnitType `json:"unit"`
+	StorageLoc          NullLocType  `json:"storageLoc"`
+	IngredientType      NullGrocType `json:"ingredientType"`
+	ImagePath           pgtype.Text  `json:"imagePath"`
+	CategoryID          *uuid.UUID   `json:"categoryId"`
+	OpenedShelfLifeDays pgtype.Int4  `json:"openedShelfLifeDays"`
+	ID                  uuid.UUID    `json:"id"`
+}
+
+// all fields are optional except for id
+func (q *Queries) UpdateIngredient(ctx context.Context, arg UpdateIngredientParams) (Ingredient, error) {
+	row := q.db.QueryRow(ctx, updateIngredient,
+		arg.Name,
+		arg.Unit,
+		arg.StorageLoc,
+		arg.IngredientType,
+		arg.ImagePath,
+		arg.CategoryID,
+		arg.OpenedShelfLifeDays,
+		arg.ID,
+	)
+	var i Ingredient
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.Name,
+		&i.Unit,
+		&i.StorageLoc,
+		&i.IngredientType,
+		&i.ImagePath,
+		&i.LastModified,
+		&i.Deleted,
+		&i.Aliases,
+		&i.ShelfLifeDays,
+		&i.OpenedShelfLifeDays,
+		&i.Visibility,
+		&i.HouseholdID,
+		&i.CategoryID,
+	)
+	return i, err
+}
+
+const upsertCatalogIngredient = `-- name: UpsertCatalogIngredient :one
+INSERT INTO
+  Ingredients (
+    name,
+    unit,
+    storage_loc,
+    ingredient_type,
+    aliases,
+    shelf_life_days,
+    opened_shelf_life_days,
+    visibility
+  )
+VALUES
+  (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    'global'
+  )
+ON CONFLICT (lower(btrim(name)))
+WHERE
+  visibility = 'global' DO
+UPDATE
+SET
+  name = EXCLUDED.name,
+  unit = EXCLUDED.unit,
+  storage_loc = EXCLUDED.storage_loc,
+  ingredient_type = EXCLUDED.ingredient_type,
+  aliases = EXCLUDED.aliases,
+  shelf_life_days = EXCLUDED.shelf_life_days,
+  opened_shelf_life_days = EXCLUDED.opened_shelf_life_days,
+  deleted = false,
+  last_modified = CURRENT_TIMESTAMP
+RETURNING
+  id, creator_id, name, unit, storage_loc, ingredient_type, image_path, last_modified, deleted, aliases, shelf_life_days, opened_shelf_life_days, visibility, household_id, category_id
+`
+
+type UpsertCatalogIngredientParams struct {
+	Name                string      `json:"name"`
+	Unit                UnitType    `json:"unit"`
+	StorageLoc          LocType     `json:"storageLoc"`
+	IngredientType      GrocType    `json:"ingredientType"`
+	Aliases             []string    `json:"aliases"`
+	ShelfLifeDays       pgtype.Int4 `json:"shelfLifeDays"`
+	OpenedShelfLifeDays pgtype.Int4 `json:"openedShelfLifeDays"`
+}
+
+// catalog rows are matched on normalized name so re-running a seed is idempotent
+func (q *Queries) UpsertCatalogIngredient(ctx context.Context, arg UpsertCatalogIngredientParams) (Ingredient, error) {
+	row := q.db.QueryRow(ctx, upsertCatalogIngredient,
+		arg.Name,
+		arg.Unit,
+		arg.StorageLoc,
+		arg.IngredientType,
+		arg.Aliases,
+		arg.ShelfLifeDays,
+		arg.OpenedShelfLifeDays,
+	)
+	var i Ingredient
+	err := row.Scan(
+		&i.ID,
+		&i.CreatorID,
+		&i.Name,
+		&i.Unit,
+		&i.StorageLoc,
+		&i.IngredientType,
+		&i.ImagePath,
+		&i.LastModified,
+		&i.Deleted,
+		&i.Aliases,
+		&i.ShelfLifeDays,
+		&i.OpenedShelfLifeDays,
+		&i.Visibility,
+		&i.HouseholdID,
+		&i.CategoryID,
+	)
+	return i, err
+}
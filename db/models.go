@@ -6,6 +6,7 @@ package db
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -14,6 +15,94 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+type DisposalOutcome string
+
+const (
+	DisposalOutcomeConsumed         DisposalOutcome = "consumed"
+	DisposalOutcomeDiscardedExpired DisposalOutcome = "discarded_expired"
+	DisposalOutcomeDiscardedSpoiled DisposalOutcome = "discarded_spoiled"
+	DisposalOutcomeDonated          DisposalOutcome = "donated"
+)
+
+func (e *DisposalOutcome) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = DisposalOutcome(s)
+	case string:
+		*e = DisposalOutcome(s)
+	default:
+		return fmt.Errorf("unsupported scan type for DisposalOutcome: %T", src)
+	}
+	return nil
+}
+
+type NullDisposalOutcome struct {
+	DisposalOutcome DisposalOutcome `json:"disposalOutcome"`
+	Valid           bool            `json:"valid"` // Valid is true if DisposalOutcome is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullDisposalOutcome) Scan(value interface{}) error {
+	if value == nil {
+		ns.DisposalOutcome, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.DisposalOutcome.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullDisposalOutcome) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.DisposalOutcome), nil
+}
+
+type EntryAction string
+
+const (
+	EntryActionCreate  EntryAction = "create"
+	EntryActionUpdate  EntryAction = "update"
+	EntryActionDelete  EntryAction = "delete"
+	EntryActionRestore EntryAction = "restore"
+)
+
+func (e *EntryAction) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = EntryAction(s)
+	case string:
+		*e = EntryAction(s)
+	default:
+		return fmt.Errorf("unsupported scan type for EntryAction: %T", src)
+	}
+	return nil
+}
+
+type NullEntryAction struct {
+	EntryAction EntryAction `json:"entryAction"`
+	Valid       bool        `json:"valid"` // Valid is true if EntryAction is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullEntryAction) Scan(value interface{}) error {
+	if value == nil {
+		ns.EntryAction, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.EntryAction.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullEntryAction) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.EntryAction), nil
+}
+
 type GrocType string
 
 const (
@@ -150,6 +239,49 @@ func (ns NullMeasureType) Value() (driver.Value, error) {
 	return string(ns.MeasureType), nil
 }
 
+type PromotionStatus string
+
+const (
+	PromotionStatusPending  PromotionStatus = "pending"
+	PromotionStatusApproved PromotionStatus = "approved"
+	PromotionStatusRejected PromotionStatus = "rejected"
+)
+
+func (e *PromotionStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = PromotionStatus(s)
+	case string:
+		*e = PromotionStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for PromotionStatus: %T", src)
+	}
+	return nil
+}
+
+type NullPromotionStatus struct {
+	PromotionStatus PromotionStatus `json:"promotionStatus"`
+	Valid           bool            `json:"valid"` // Valid is true if PromotionStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullPromotionStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.PromotionStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.PromotionStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullPromotionStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.PromotionStatus), nil
+}
+
 type UnitType string
 
 const (
@@ -193,20 +325,108 @@ func (ns NullUnitType) Value() (driver.Value, error) {
 	return string(ns.UnitType), nil
 }
 
+type VisibilityType string
+
+const (
+	VisibilityTypeGlobal    VisibilityType = "global"
+	VisibilityTypePrivate   VisibilityType = "private"
+	VisibilityTypeHousehold VisibilityType = "household"
+)
+
+func (e *VisibilityType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = VisibilityType(s)
+	case string:
+		*e = VisibilityType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for VisibilityType: %T", src)
+	}
+	return nil
+}
+
+type NullVisibilityType struct {
+	VisibilityType VisibilityType `json:"visibilityType"`
+	Valid          bool           `json:"valid"` // Valid is true if VisibilityType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullVisibilityType) Scan(value interface{}) error {
+	if value == nil {
+		ns.VisibilityType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.VisibilityType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullVisibilityType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.VisibilityType), nil
+}
+
 type Favorite struct {
 	UserID   uuid.UUID `json:"userId"`
 	RecipeID uuid.UUID `json:"recipeId"`
 }
 
+type Household struct {
+	ID          uuid.UUID   `json:"id"`
+	CreatorID   *uuid.UUID  `json:"creatorId"`
+	Name        string      `json:"name"`
+	DateCreated pgtype.Date `json:"dateCreated"`
+}
+
+type Householdmember struct {
+	HouseholdID uuid.UUID `json:"householdId"`
+	UserID      uuid.UUID `json:"userId"`
+}
+
 type Ingredient struct {
-	ID             uuid.UUID   `json:"id"`
-	CreatorID      *uuid.UUID  `json:"creatorId"`
-	Name           string      `json:"name"`
-	Unit           UnitType    `json:"unit"`
-	StorageLoc     LocType     `json:"storageLoc"`
-	IngredientType GrocType    `json:"ingredientType"`
-	ImagePath      pgtype.Text `json:"imagePath"`
-	LastModified   time.Time   `json:"lastModified"`
+	ID                  uuid.UUID      `json:"id"`
+	CreatorID           *uuid.UUID     `json:"creatorId"`
+	Name                string         `json:"name"`
+	Unit                UnitType       `json:"unit"`
+	StorageLoc          LocType        `json:"storageLoc"`
+	IngredientType      GrocType       `json:"ingredientType"`
+	ImagePath           pgtype.Text    `json:"imagePath"`
+	LastModified        time.Time      `json:"lastModified"`
+	Deleted             bool           `json:"deleted"`
+	Aliases             []string       `json:"aliases"`
+	ShelfLifeDays       pgtype.Int4    `json:"shelfLifeDays"`
+	OpenedShelfLifeDays pgtype.Int4    `json:"openedShelfLifeDays"`
+	Visibility          VisibilityType `json:"visibility"`
+	HouseholdID         *uuid.UUID     `json:"householdId"`
+	CategoryID          *uuid.UUID     `json:"categoryId"`
+}
+
+type Ingredientcategory struct {
+	ID           uuid.UUID  `json:"id"`
+	ParentID     *uuid.UUID `json:"parentId"`
+	Name         string     `json:"name"`
+	LastModified time.Time  `json:"lastModified"`
+}
+
+type Ingredientpromotion struct {
+	ID           uuid.UUID       `json:"id"`
+	IngredientID uuid.UUID       `json:"ingredientId"`
+	RequesterID  *uuid.UUID      `json:"requesterId"`
+	ReviewerID   *uuid.UUID      `json:"reviewerId"`
+	Status       PromotionStatus `json:"status"`
+	Note         pgtype.Text     `json:"note"`
+	DateCreated  time.Time       `json:"dateCreated"`
+	LastModified time.Time       `json:"lastModified"`
+}
+
+type Parlevel struct {
+	UserID       uuid.UUID       `json:"userId"`
+	IngredientID uuid.UUID       `json:"ingredientId"`
+	MinQuantity  decimal.Decimal `json:"minQuantity"`
+	Unit         pgtype.Text     `json:"unit"`
+	LastModified time.Time       `json:"lastModified"`
 }
 
 type Recipe struct {
@@ -222,6 +442,14 @@ type Recipe struct {
 	ImagePath   pgtype.Text     `json:"imagePath"`
 }
 
+type Recipecategoryrequirement struct {
+	RecipeID          uuid.UUID       `json:"recipeId"`
+	CategoryID        uuid.UUID       `json:"categoryId"`
+	Quantity          decimal.Decimal `json:"quantity"`
+	AuthorUnitType    UnitType        `json:"authorUnitType"`
+	AuthorMeasureType MeasureType     `json:"authorMeasureType"`
+}
+
 type Recipeingredient struct {
 	RecipeID          uuid.UUID       `json:"recipeId"`
 	IngredientID      uuid.UUID       `json:"ingredientId"`
@@ -239,35 +467,121 @@ type Recipeingredientsview struct {
 	RecipeID       uuid.UUID       `json:"recipeId"`
 }
 
+type Shoppinglist struct {
+	ID           uuid.UUID `json:"id"`
+	UserID       uuid.UUID `json:"userId"`
+	Name         string    `json:"name"`
+	DateCreated  time.Time `json:"dateCreated"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+type Shoppinglistitem struct {
+	ID           uuid.UUID       `json:"id"`
+	ListID       uuid.UUID       `json:"listId"`
+	IngredientID uuid.UUID       `json:"ingredientId"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	Unit         pgtype.Text     `json:"unit"`
+	Note         pgtype.Text     `json:"note"`
+	Checked      bool            `json:"checked"`
+	LastModified time.Time       `json:"lastModified"`
+}
+
+type Stocktake struct {
+	ID          uuid.UUID   `json:"id"`
+	UserID      uuid.UUID   `json:"userId"`
+	LocationID  *uuid.UUID  `json:"locationId"`
+	StorageLoc  NullLocType `json:"storageLoc"`
+	DateCreated time.Time   `json:"dateCreated"`
+	AppliedAt   *time.Time  `json:"appliedAt"`
+}
+
+type Stocktakecount struct {
+	StocktakeID  uuid.UUID       `json:"stocktakeId"`
+	IngredientID uuid.UUID       `json:"ingredientId"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	LastModified time.Time       `json:"lastModified"`
+}
+
+type Storagelocation struct {
+	ID           uuid.UUID `json:"id"`
+	UserID       uuid.UUID `json:"userId"`
+	Name         string    `json:"name"`
+	BaseLoc      LocType   `json:"baseLoc"`
+	LastModified time.Time `json:"lastModified"`
+}
+
 type User struct {
-	ID           uuid.UUID   `json:"id"`
-	Email        string      `json:"email"`
-	Name         string      `json:"name"`
-	DateJoined   pgtype.Date `json:"dateJoined"`
-	PrefMeasure  MeasureType `json:"prefMeasure"`
-	LastModified time.Time   `json:"lastModified"`
-	ProfilePic   pgtype.Text `json:"profilePic"`
+	ID              uuid.UUID   `json:"id"`
+	Email           string      `json:"email"`
+	Name            string      `json:"name"`
+	DateJoined      pgtype.Date `json:"dateJoined"`
+	PrefMeasure     MeasureType `json:"prefMeasure"`
+	LastModified    time.Time   `json:"lastModified"`
+	ProfilePic      pgtype.Text `json:"profilePic"`
+	IsAdmin         bool        `json:"isAdmin"`
+	MergeDuplicates bool        `json:"mergeDuplicates"`
+}
+
+type Useritemdisposal struct {
+	ID          uuid.UUID           `json:"id"`
+	EntryID     uuid.UUID           `json:"entryId"`
+	UserID      *uuid.UUID          `json:"userId"`
+	Outcome     DisposalOutcome     `json:"outcome"`
+	Quantity    decimal.Decimal     `json:"quantity"`
+	Cost        decimal.NullDecimal `json:"cost"`
+	DateCreated time.Time           `json:"dateCreated"`
 }
 
 type Useritementry struct {
-	ID             uuid.UUID           `json:"id"`
-	UserID         *uuid.UUID          `json:"userId"`
-	IngredientID   *uuid.UUID          `json:"ingredientId"`
-	Quantity       decimal.Decimal     `json:"quantity"`
-	Price          decimal.NullDecimal `json:"price"`
-	ExpirationDate *time.Time          `json:"expirationDate"`
-	LastModified   time.Time           `json:"lastModified"`
-	Deleted        bool                `json:"deleted"`
+	ID              uuid.UUID           `json:"id"`
+	UserID          *uuid.UUID          `json:"userId"`
+	IngredientID    *uuid.UUID          `json:"ingredientId"`
+	Quantity        decimal.Decimal     `json:"quantity"`
+	Price           decimal.NullDecimal `json:"price"`
+	ExpirationDate  *time.Time          `json:"expirationDate"`
+	LastModified    time.Time           `json:"lastModified"`
+	Deleted         bool                `json:"deleted"`
+	DateCreated     time.Time           `json:"dateCreated"`
+	InitialQuantity decimal.NullDecimal `json:"initialQuantity"`
+	LocationID      *uuid.UUID          `json:"locationId"`
+	EnteredUnit     pgtype.Text         `json:"enteredUnit"`
+	Brand           pgtype.Text         `json:"brand"`
+	Store           pgtype.Text         `json:"store"`
+	PurchaseDate    *time.Time          `json:"purchaseDate"`
+	Note            pgtype.Text         `json:"note"`
+	Tags            []string            `json:"tags"`
+	OpenedAt        *time.Time          `json:"openedAt"`
+	RecipeID        *uuid.UUID          `json:"recipeId"`
+	StorageLoc      NullLocType         `json:"storageLoc"`
+	UnitCost        decimal.NullDecimal `json:"unitCost"`
+}
+
+type Useritementryhistory struct {
+	ID          uuid.UUID       `json:"id"`
+	EntryID     uuid.UUID       `json:"entryId"`
+	UserID      *uuid.UUID      `json:"userId"`
+	ActorID     *uuid.UUID      `json:"actorId"`
+	Action      EntryAction     `json:"action"`
+	OldValue    json.RawMessage `json:"oldValue"`
+	NewValue    json.RawMessage `json:"newValue"`
+	DeviceID    pgtype.Text     `json:"deviceId"`
+	Source      string          `json:"source"`
+	DateCreated time.Time       `json:"dateCreated"`
 }
 
 type Userpantryview struct {
 	UserID                uuid.UUID       `json:"userId"`
 	UserEmail             string          `json:"userEmail"`
 	UserMeasurementSystem MeasureType     `json:"userMeasurementSystem"`
+	IngredientID          *uuid.UUID      `json:"ingredientId"`
+	RecipeID              *uuid.UUID      `json:"recipeId"`
 	IngredientName        string          `json:"ingredientName"`
 	Quantity              decimal.Decimal `json:"quantity"`
 	ExpirationDate        interface{}     `json:"expirationDate"`
+	Opened                pgtype.Bool     `json:"opened"`
 	Unit                  UnitType        `json:"unit"`
 	StorageLoc            LocType         `json:"storageLoc"`
 	IngredientType        GrocType        `json:"ingredientType"`
+	LocationID            *uuid.UUID      `json:"locationId"`
+	LocationName          pgtype.Text     `json:"locationName"`
 }
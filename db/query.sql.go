@@ -161,7 +161,7 @@ VALUES
     $4
   )
 RETURNING
-  id, email, name, date_joined, pref_measure, last_modified, profile_pic
+  id, email, name, date_joined, pref_measure, last_modified, profile_pic, is_admin, merge_duplicates
 `
 
 type CreateUserParams struct {
@@ -187,6 +187,8 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.PrefMeasure,
 		&i.LastModified,
 		&i.ProfilePic,
+		&i.IsAdmin,
+		&i.MergeDuplicates,
 	)
 	return i, err
 }
@@ -198,7 +200,16 @@ INSERT INTO
     ingredient_id,
     quantity,
     price,
-    expiration_date
+    expiration_date,
+    initial_quantity,
+    location_id,
+    entered_unit,
+    brand,
+    store,
+    purchase_date,
+    note,
+    tags,
+    storage_loc
   )
 VALUES
   (
@@ -206,10 +217,19 @@ VALUES
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $3,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    COALESCE($12::text[], '{}'),
+    $13
   )
 RETURNING
-  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted
+  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted, date_created, initial_quantity, location_id, entered_unit, brand, store, purchase_date, note, tags, opened_at, recipe_id, storage_loc, unit_cost
 `
 
 type CreateUserItemEntryParams struct {
@@ -218,6 +238,14 @@ type CreateUserItemEntryParams struct {
 	Quantity       decimal.Decimal     `json:"quantity"`
 	Price          decimal.NullDecimal `json:"price"`
 	ExpirationDate *time.Time          `json:"expirationDate"`
+	LocationID     *uuid.UUID          `json:"locationId"`
+	EnteredUnit    pgtype.Text         `json:"enteredUnit"`
+	Brand          pgtype.Text         `json:"brand"`
+	Store          pgtype.Text         `json:"store"`
+	PurchaseDate   *time.Time          `json:"purchaseDate"`
+	Note           pgtype.Text         `json:"note"`
+	Tags           []string            `json:"tags"`
+	StorageLoc     NullLocType         `json:"storageLoc"`
 }
 
 func (q *Queries) CreateUserItemEntry(ctx context.Context, arg CreateUserItemEntryParams) (Useritementry, error) {
@@ -227,6 +255,14 @@ func (q *Queries) CreateUserItemEntry(ctx context.Context, arg CreateUserItemEnt
 		arg.Quantity,
 		arg.Price,
 		arg.ExpirationDate,
+		arg.LocationID,
+		arg.EnteredUnit,
+		arg.Brand,
+		arg.Store,
+		arg.PurchaseDate,
+		arg.Note,
+		arg.Tags,
+		arg.StorageLoc,
 	)
 	var i Useritementry
 	err := row.Scan(
@@ -238,11 +274,24 @@ func (q *Queries) CreateUserItemEntry(ctx context.Context, arg CreateUserItemEnt
 		&i.ExpirationDate,
 		&i.LastModified,
 		&i.Deleted,
+		&i.DateCreated,
+		&i.InitialQuantity,
+		&i.LocationID,
+		&i.EnteredUnit,
+		&i.Brand,
+		&i.Store,
+		&i.PurchaseDate,
+		&i.Note,
+		&i.Tags,
+		&i.OpenedAt,
+		&i.RecipeID,
+		&i.StorageLoc,
+		&i.UnitCost,
 	)
 	return i, err
 }
 
-const deleteUserItemEntry = `-- name: DeleteUserItemEntry :exec
+const deleteUserItemEntry = `-- name: DeleteUserItemEntry :one
 UPDATE
   UserItemEntries
 SET
@@ -250,11 +299,44 @@ SET
   last_modified = CURRENT_TIMESTAMP
 WHERE
   id = $1
+  AND user_id = $2
+  AND deleted = false
+RETURNING
+  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted, date_created, initial_quantity, location_id, entered_unit, brand, store, purchase_date, note, tags, opened_at, recipe_id, storage_loc, unit_cost
 `
 
-func (q *Queries) DeleteUserItemEntry(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, deleteUserItemEntry, id)
-	return err
+type DeleteUserItemEntryParams struct {
+	ID     uuid.UUID  `json:"id"`
+	UserID *uuid.UUID `json:"userId"`
+}
+
+func (q *Queries) DeleteUserItemEntry(ctx context.Context, arg DeleteUserItemEntryParams) (Useritementry, error) {
+	row := q.db.QueryRow(ctx, deleteUserItemEntry, arg.ID, arg.UserID)
+	var i Useritementry
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.IngredientID,
+		&i.Quantity,
+		&i.Price,
+		&i.ExpirationDate,
+		&i.LastModified,
+		&i.Deleted,
+		&i.DateCreated,
+		&i.InitialQuantity,
+		&i.LocationID,
+		&i.EnteredUnit,
+		&i.Brand,
+		&i.Store,
+		&i.PurchaseDate,
+		&i.Note,
+		&i.Tags,
+		&i.OpenedAt,
+		&i.RecipeID,
+		&i.StorageLoc,
+		&i.UnitCost,
+	)
+	return i, err
 }
 
 const getFavorites = `-- name: GetFavorites :many
@@ -359,7 +441,7 @@ func (q *Queries) GetRecipeIngredients(ctx context.Context, recipeID uuid.UUID)
 
 const getUser = `-- name: GetUser :one
 SELECT
-  id, email, name, date_joined, pref_measure, last_modified, profile_pic
+  id, email, name, date_joined, pref_measure, last_modified, profile_pic, is_admin, merge_duplicates
 FROM
   Users u
 WHERE
@@ -390,13 +472,15 @@ func (q *Queries) GetUser(ctx context.Context, arg GetUserParams) (User, error)
 		&i.PrefMeasure,
 		&i.LastModified,
 		&i.ProfilePic,
+		&i.IsAdmin,
+		&i.MergeDuplicates,
 	)
 	return i, err
 }
 
 const getUserItemEntries = `-- name: GetUserItemEntries :many
 SELECT
-  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted
+  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted, date_created, initial_quantity, location_id, entered_unit, brand, store, purchase_date, note, tags, opened_at, recipe_id, storage_loc, unit_cost
 FROM
   UserItemEntries
 WHERE
@@ -422,6 +506,19 @@ func (q *Queries) GetUserItemEntries(ctx context.Context, userID *uuid.UUID) ([]
 			&i.ExpirationDate,
 			&i.LastModified,
 			&i.Deleted,
+			&i.DateCreated,
+			&i.InitialQuantity,
+			&i.LocationID,
+			&i.EnteredUnit,
+			&i.Brand,
+			&i.Store,
+			&i.PurchaseDate,
+			&i.Note,
+			&i.Tags,
+			&i.OpenedAt,
+			&i.RecipeID,
+			&i.StorageLoc,
+			&i.UnitCost,
 		); err != nil {
 			return nil, err
 		}
@@ -435,7 +532,7 @@ func (q *Queries) GetUserItemEntries(ctx context.Context, userID *uuid.UUID) ([]
 
 const getUserItemEntriesSinceTime = `-- name: GetUserItemEntriesSinceTime :many
 SELECT
-  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted
+  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted, date_created, initial_quantity, location_id, entered_unit, brand, store, purchase_date, note, tags, opened_at, recipe_id, storage_loc, unit_cost
 FROM
   UserItemEntries
 WHERE
@@ -466,6 +563,19 @@ func (q *Queries) GetUserItemEntriesSinceTime(ctx context.Context, arg GetUserIt
 			&i.ExpirationDate,
 			&i.LastModified,
 			&i.Deleted,
+			&i.DateCreated,
+			&i.InitialQuantity,
+			&i.LocationID,
+			&i.EnteredUnit,
+			&i.Brand,
+			&i.Store,
+			&i.PurchaseDate,
+			&i.Note,
+			&i.Tags,
+			&i.OpenedAt,
+			&i.RecipeID,
+			&i.StorageLoc,
+			&i.UnitCost,
 		); err != nil {
 			return nil, err
 		}
@@ -581,6 +691,57 @@ func (q *Queries) ListRecipes(ctx context.Context) ([]Recipe, error) {
 	return items, nil
 }
 
+const openUserItemEntry = `-- name: OpenUserItemEntry :one
+UPDATE
+  UserItemEntries
+SET
+  opened_at = $1,
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = $2
+  AND user_id = $3
+  AND deleted = false
+  AND opened_at IS NULL
+RETURNING
+  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted, date_created, initial_quantity, location_id, entered_unit, brand, store, purchase_date, note, tags, opened_at, recipe_id, storage_loc, unit_cost
+`
+
+type OpenUserItemEntryParams struct {
+	OpenedAt *time.Time `json:"openedAt"`
+	ID       uuid.UUID  `json:"id"`
+	UserID   *uuid.UUID `json:"userId"`
+}
+
+// only sets opened_at once, already opened entries return no rows
+func (q *Queries) OpenUserItemEntry(ctx context.Context, arg OpenUserItemEntryParams) (Useritementry, error) {
+	row := q.db.QueryRow(ctx, openUserItemEntry, arg.OpenedAt, arg.ID, arg.UserID)
+	var i Useritementry
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.IngredientID,
+		&i.Quantity,
+		&i.Price,
+		&i.ExpirationDate,
+		&i.LastModified,
+		&i.Deleted,
+		&i.DateCreated,
+		&i.InitialQuantity,
+		&i.LocationID,
+		&i.EnteredUnit,
+		&i.Brand,
+		&i.Store,
+		&i.PurchaseDate,
+		&i.Note,
+		&i.Tags,
+		&i.OpenedAt,
+		&i.RecipeID,
+		&i.StorageLoc,
+		&i.UnitCost,
+	)
+	return i, err
+}
+
 const removeFavorite = `-- name: RemoveFavorite :exec
 DELETE FROM Favorites
 WHERE
@@ -656,20 +817,25 @@ SET
     $4::measure_type,
     pref_measure
   ),
-  profile_pic = COALESCE($5, profile_pic)
+  profile_pic = COALESCE($5, profile_pic),
+  merge_duplicates = COALESCE(
+    $6,
+    merge_duplicates
+  )
 WHERE
-  id = $6
+  id = $7
 RETURNING
-  id, email, name, date_joined, pref_measure, last_modified, profile_pic
+  id, email, name, date_joined, pref_measure, last_modified, profile_pic, is_admin, merge_duplicates
 `
 
 type UpdateUserParams struct {
-	Email       pgtype.Text     `json:"email"`
-	Name        pgtype.Text     `json:"name"`
-	DateJoined  pgtype.Date     `json:"dateJoined"`
-	PrefMeasure NullMeasureType `json:"prefMeasure"`
-	ProfilePic  pgtype.Text     `json:"profilePic"`
-	ID          uuid.UUID       `json:"id"`
+	Email           pgtype.Text     `json:"email"`
+	Name            pgtype.Text     `json:"name"`
+	DateJoined      pgtype.Date     `json:"dateJoined"`
+	PrefMeasure     NullMeasureType `json:"prefMeasure"`
+	ProfilePic      pgtype.Text     `json:"profilePic"`
+	MergeDuplicates pgtype.Bool     `json:"mergeDuplicates"`
+	ID              uuid.UUID       `json:"id"`
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
@@ -679,6 +845,7 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) error {
 		arg.DateJoined,
 		arg.PrefMeasure,
 		arg.ProfilePic,
+		arg.MergeDuplicates,
 		arg.ID,
 	)
 	return err
@@ -694,8 +861,10 @@ SET
   last_modified = CURRENT_TIMESTAMP
 WHERE
   id = $4
+  AND user_id = $5
+  AND deleted = false
 RETURNING
-  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted
+  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted, date_created, initial_quantity, location_id, entered_unit, brand, store, purchase_date, note, tags, opened_at, recipe_id, storage_loc, unit_cost
 `
 
 type UpdateUserItemEntryParams struct {
@@ -703,14 +872,17 @@ type UpdateUserItemEntryParams struct {
 	Price          decimal.NullDecimal `json:"price"`
 	ExpirationDate *time.Time          `json:"expirationDate"`
 	ID             uuid.UUID           `json:"id"`
+	UserID         *uuid.UUID          `json:"userId"`
 }
 
+// only touches entries owned by user_id, so other users' ids return no rows
 func (q *Queries) UpdateUserItemEntry(ctx context.Context, arg UpdateUserItemEntryParams) (Useritementry, error) {
 	row := q.db.QueryRow(ctx, updateUserItemEntry,
 		arg.Quantity,
 		arg.Price,
 		arg.ExpirationDate,
 		arg.ID,
+		arg.UserID,
 	)
 	var i Useritementry
 	err := row.Scan(
@@ -722,6 +894,19 @@ func (q *Queries) UpdateUserItemEntry(ctx context.Context, arg UpdateUserItemEnt
 		&i.ExpirationDate,
 		&i.LastModified,
 		&i.Deleted,
+		&i.DateCreated,
+		&i.InitialQuantity,
+		&i.LocationID,
+		&i.EnteredUnit,
+		&i.Brand,
+		&i.Store,
+		&i.PurchaseDate,
+		&i.Note,
+		&i.Tags,
+		&i.OpenedAt,
+		&i.RecipeID,
+		&i.StorageLoc,
+		&i.UnitCost,
 	)
 	return i, err
 }
@@ -735,7 +920,15 @@ INSERT INTO UserItemEntries (
   price,
   expiration_date,
   last_modified,
-  deleted
+  deleted,
+  initial_quantity,
+  entered_unit,
+  brand,
+  store,
+  purchase_date,
+  note,
+  tags,
+  opened_at
 ) VALUES (
   $1,
   $2,
@@ -744,7 +937,15 @@ INSERT INTO UserItemEntries (
   $5,
   $6,
   $7,
-  $8
+  $8,
+  $4,
+  $9,
+  $10,
+  $11,
+  $12,
+  $13,
+  COALESCE($14::text[], '{}'),
+  $15
 )
 ON CONFLICT (id) DO UPDATE
 SET
@@ -754,10 +955,18 @@ SET
   price = EXCLUDED.price,
   expiration_date = EXCLUDED.expiration_date,
   last_modified = EXCLUDED.last_modified,
-  deleted = EXCLUDED.deleted
+  deleted = EXCLUDED.deleted,
+  entered_unit = EXCLUDED.entered_unit,
+  brand = EXCLUDED.brand,
+  store = EXCLUDED.store,
+  purchase_date = EXCLUDED.purchase_date,
+  note = EXCLUDED.note,
+  tags = EXCLUDED.tags,
+  opened_at = EXCLUDED.opened_at
 WHERE
-  EXCLUDED.last_modified > UserItemEntries.last_modified
-RETURNING id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted
+  UserItemEntries.user_id = EXCLUDED.user_id
+  AND EXCLUDED.last_modified > UserItemEntries.last_modified
+RETURNING id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted, date_created, initial_quantity, location_id, entered_unit, brand, store, purchase_date, note, tags, opened_at, recipe_id, storage_loc, unit_cost
 `
 
 type UpsertUserItemEntryParams struct {
@@ -769,6 +978,13 @@ type UpsertUserItemEntryParams struct {
 	ExpirationDate *time.Time          `json:"expirationDate"`
 	LastModified   time.Time           `json:"lastModified"`
 	Deleted        bool                `json:"deleted"`
+	EnteredUnit    pgtype.Text         `json:"enteredUnit"`
+	Brand          pgtype.Text         `json:"brand"`
+	Store          pgtype.Text         `json:"store"`
+	PurchaseDate   *time.Time          `json:"purchaseDate"`
+	Note           pgtype.Text         `json:"note"`
+	Tags           []string            `json:"tags"`
+	OpenedAt       *time.Time          `json:"openedAt"`
 }
 
 func (q *Queries) UpsertUserItemEntry(ctx context.Context, arg UpsertUserItemEntryParams) (Useritementry, error) {
@@ -781,6 +997,13 @@ func (q *Queries) UpsertUserItemEntry(ctx context.Context, arg UpsertUserItemEnt
 		arg.ExpirationDate,
 		arg.LastModified,
 		arg.Deleted,
+		arg.EnteredUnit,
+		arg.Brand,
+		arg.Store,
+		arg.PurchaseDate,
+		arg.Note,
+		arg.Tags,
+		arg.OpenedAt,
 	)
 	var i Useritementry
 	err := row.Scan(
@@ -792,6 +1015,19 @@ func (q *Queries) UpsertUserItemEntry(ctx context.Context, arg UpsertUserItemEnt
 		&i.ExpirationDate,
 		&i.LastModified,
 		&i.Deleted,
+		&i.DateCreated,
+		&i.InitialQuantity,
+		&i.LocationID,
+		&i.EnteredUnit,
+		&i.Brand,
+		&i.Store,
+		&i.PurchaseDate,
+		&i.Note,
+		&i.Tags,
+		&i.OpenedAt,
+		&i.RecipeID,
+		&i.StorageLoc,
+		&i.UnitCost,
 	)
 	return i, err
 }
@@ -7,10 +7,316 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
+const createLeftoverEntry = `-- name: CreateLeftoverEntry :one
+INSERT INTO
+  UserItemEntries (
+    user_id,
+    recipe_id,
+    quantity,
+    initial_quantity,
+    expiration_date,
+    storage_loc,
+    entered_unit
+  )
+VALUES
+  (
+    $1,
+    $2,
+    $3,
+    $3,
+    $4,
+    $5,
+    'count'
+  )
+RETURNING
+  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted, date_created, initial_quantity, location_id, entered_unit, brand, store, purchase_date, note, tags, opened_at, recipe_id, storage_loc, unit_cost
+`
+
+type CreateLeftoverEntryParams struct {
+	UserID         *uuid.UUID      `json:"userId"`
+	RecipeID       *uuid.UUID      `json:"recipeId"`
+	Quantity       decimal.Decimal `json:"quantity"`
+	ExpirationDate *time.Time      `json:"expirationDate"`
+	StorageLoc     NullLocType     `json:"storageLoc"`
+}
+
+func (q *Queries) CreateLeftoverEntry(ctx context.Context, arg CreateLeftoverEntryParams) (Useritementry, error) {
+	row := q.db.QueryRow(ctx, createLeftoverEntry,
+		arg.UserID,
+		arg.RecipeID,
+		arg.Quantity,
+		arg.ExpirationDate,
+		arg.StorageLoc,
+	)
+	var i Useritementry
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.IngredientID,
+		&i.Quantity,
+		&i.Price,
+		&i.ExpirationDate,
+		&i.LastModified,
+		&i.Deleted,
+		&i.DateCreated,
+		&i.InitialQuantity,
+		&i.LocationID,
+		&i.EnteredUnit,
+		&i.Brand,
+		&i.Store,
+		&i.PurchaseDate,
+		&i.Note,
+		&i.Tags,
+		&i.OpenedAt,
+		&i.RecipeID,
+		&i.StorageLoc,
+		&i.UnitCost,
+	)
+	return i, err
+}
+
+const getCompactableUserItemEntries = `-- name: GetCompactableUserItemEntries :many
+SELECT
+  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted, date_created, initial_quantity, location_id, entered_unit, brand, store, purchase_date, note, tags, opened_at, recipe_id, storage_loc, unit_cost
+FROM
+  UserItemEntries
+WHERE
+  user_id = $1
+  AND deleted = false
+  AND opened_at IS NULL
+ORDER BY
+  ingredient_id,
+  date_created,
+  id
+FOR UPDATE
+`
+
+// live sealed entries of every ingredient, locked for compaction
+func (q *Queries) GetCompactableUserItemEntries(ctx context.Context, userID *uuid.UUID) ([]Useritementry, error) {
+	rows, err := q.db.Query(ctx, getCompactableUserItemEntries, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Useritementry
+	for rows.Next() {
+		var i Useritementry
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.IngredientID,
+			&i.Quantity,
+			&i.Price,
+			&i.ExpirationDate,
+			&i.LastModified,
+			&i.Deleted,
+			&i.DateCreated,
+			&i.InitialQuantity,
+			&i.LocationID,
+			&i.EnteredUnit,
+			&i.Brand,
+			&i.Store,
+			&i.PurchaseDate,
+			&i.Note,
+			&i.Tags,
+			&i.OpenedAt,
+			&i.RecipeID,
+			&i.StorageLoc,
+			&i.UnitCost,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getConsumableUserItemEntries = `-- name: GetConsumableUserItemEntries :many
+SELECT
+  ui.id, ui.user_id, ui.ingredient_id, ui.quantity, ui.price, ui.expiration_date, ui.last_modified, ui.deleted, ui.date_created, ui.initial_quantity, ui.location_id, ui.entered_unit, ui.brand, ui.store, ui.purchase_date, ui.note, ui.tags, ui.opened_at, ui.recipe_id, ui.storage_loc, ui.unit_cost
+FROM
+  UserItemEntries ui
+  JOIN Ingredients i ON ui.ingredient_id = i.id
+WHERE
+  ui.user_id = $1
+  AND ui.ingredient_id = $2
+  AND ui.deleted = false
+ORDER BY
+  LEAST(
+    ui.expiration_date,
+    ui.opened_at + make_interval(days => i.opened_shelf_life_days)
+  ) NULLS LAST,
+  ui.last_modified
+FOR UPDATE OF ui
+`
+
+type GetConsumableUserItemEntriesParams struct {
+	UserID       *uuid.UUID `json:"userId"`
+	IngredientID *uuid.UUID `json:"ingredientId"`
+}
+
+// locks the user's live entries for an ingredient, first-expiring first,
+// counting opened entries by when they go off once opened
+func (q *Queries) GetConsumableUserItemEntries(ctx context.Context, arg GetConsumableUserItemEntriesParams) ([]Useritementry, error) {
+	rows, err := q.db.Query(ctx, getConsumableUserItemEntries, arg.UserID, arg.IngredientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Useritementry
+	for rows.Next() {
+		var i Useritementry
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.IngredientID,
+			&i.Quantity,
+			&i.Price,
+			&i.ExpirationDate,
+			&i.LastModified,
+			&i.Deleted,
+			&i.DateCreated,
+			&i.InitialQuantity,
+			&i.LocationID,
+			&i.EnteredUnit,
+			&i.Brand,
+			&i.Store,
+			&i.PurchaseDate,
+			&i.Note,
+			&i.Tags,
+			&i.OpenedAt,
+			&i.RecipeID,
+			&i.StorageLoc,
+			&i.UnitCost,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMergeCandidates = `-- name: GetMergeCandidates :many
+SELECT
+  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted, date_created, initial_quantity, location_id, entered_unit, brand, store, purchase_date, note, tags, opened_at, recipe_id, storage_loc, unit_cost
+FROM
+  UserItemEntries
+WHERE
+  user_id = $1
+  AND ingredient_id = $2
+  AND deleted = false
+  AND opened_at IS NULL
+ORDER BY
+  date_created,
+  id
+FOR UPDATE
+`
+
+type GetMergeCandidatesParams struct {
+	UserID       *uuid.UUID `json:"userId"`
+	IngredientID *uuid.UUID `json:"ingredientId"`
+}
+
+// live sealed entries an ingredient's new stock could be merged into, oldest
+// first; opened entries keep their own expiry so they are never merged
+func (q *Queries) GetMergeCandidates(ctx context.Context, arg GetMergeCandidatesParams) ([]Useritementry, error) {
+	rows, err := q.db.Query(ctx, getMergeCandidates, arg.UserID, arg.IngredientID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Useritementry
+	for rows.Next() {
+		var i Useritementry
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.IngredientID,
+			&i.Quantity,
+			&i.Price,
+			&i.ExpirationDate,
+			&i.LastModified,
+			&i.Deleted,
+			&i.DateCreated,
+			&i.InitialQuantity,
+			&i.LocationID,
+			&i.EnteredUnit,
+			&i.Brand,
+			&i.Store,
+			&i.PurchaseDate,
+			&i.Note,
+			&i.Tags,
+			&i.OpenedAt,
+			&i.RecipeID,
+			&i.StorageLoc,
+			&i.UnitCost,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUserItemEntry = `-- name: GetUserItemEntry :one
+SELECT
+  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted, date_created, initial_quantity, location_id, entered_unit, brand, store, purchase_date, note, tags, opened_at, recipe_id, storage_loc, unit_cost
+FROM
+  UserItemEntries
+WHERE
+  id = $1
+  AND user_id = $2
+`
+
+type GetUserItemEntryParams struct {
+	ID     uuid.UUID  `json:"id"`
+	UserID *uuid.UUID `json:"userId"`
+}
+
+func (q *Queries) GetUserItemEntry(ctx context.Context, arg GetUserItemEntryParams) (Useritementry, error) {
+	row := q.db.QueryRow(ctx, getUserItemEntry, arg.ID, arg.UserID)
+	var i Useritementry
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.IngredientID,
+		&i.Quantity,
+		&i.Price,
+		&i.ExpirationDate,
+		&i.LastModified,
+		&i.Deleted,
+		&i.DateCreated,
+		&i.InitialQuantity,
+		&i.LocationID,
+		&i.EnteredUnit,
+		&i.Brand,
+		&i.Store,
+		&i.PurchaseDate,
+		&i.Note,
+		&i.Tags,
+		&i.OpenedAt,
+		&i.RecipeID,
+		&i.StorageLoc,
+		&i.UnitCost,
+	)
+	return i, err
+}
+
 const getUserItemEntryIngredientIdsForUser = `-- name: GetUserItemEntryIngredientIdsForUser :many
 SELECT
   ingredient_id
@@ -39,3 +345,130 @@ func (q *Queries) GetUserItemEntryIngredientIdsForUser(ctx context.Context, user
 	}
 	return items, nil
 }
+
+const listUserItemEntries = `-- name: ListUserItemEntries :many
+SELECT
+  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted, date_created, initial_quantity, location_id, entered_unit, brand, store, purchase_date, note, tags, opened_at, recipe_id, storage_loc, unit_cost
+FROM
+  UserItemEntries
+WHERE
+  user_id = $1
+  AND (
+    $2::boolean
+    OR deleted = false
+  )
+ORDER BY
+  expiration_date NULLS LAST,
+  last_modified
+`
+
+type ListUserItemEntriesParams struct {
+	UserID         *uuid.UUID `json:"userId"`
+	IncludeDeleted bool       `json:"includeDeleted"`
+}
+
+func (q *Queries) ListUserItemEntries(ctx context.Context, arg ListUserItemEntriesParams) ([]Useritementry, error) {
+	rows, err := q.db.Query(ctx, listUserItemEntries, arg.UserID, arg.IncludeDeleted)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Useritementry
+	for rows.Next() {
+		var i Useritementry
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.IngredientID,
+			&i.Quantity,
+			&i.Price,
+			&i.ExpirationDate,
+			&i.LastModified,
+			&i.Deleted,
+			&i.DateCreated,
+			&i.InitialQuantity,
+			&i.LocationID,
+			&i.EnteredUnit,
+			&i.Brand,
+			&i.Store,
+			&i.PurchaseDate,
+			&i.Note,
+			&i.Tags,
+			&i.OpenedAt,
+			&i.RecipeID,
+			&i.StorageLoc,
+			&i.UnitCost,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const mergeUserItemEntry = `-- name: MergeUserItemEntry :one
+UPDATE
+  UserItemEntries
+SET
+  quantity = $1,
+  unit_cost = $2,
+  expiration_date = $3,
+  tags = $4,
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = $5
+  AND user_id = $6
+  AND deleted = false
+RETURNING
+  id, user_id, ingredient_id, quantity, price, expiration_date, last_modified, deleted, date_created, initial_quantity, location_id, entered_unit, brand, store, purchase_date, note, tags, opened_at, recipe_id, storage_loc, unit_cost
+`
+
+type MergeUserItemEntryParams struct {
+	Quantity       decimal.Decimal     `json:"quantity"`
+	UnitCost       decimal.NullDecimal `json:"unitCost"`
+	ExpirationDate *time.Time          `json:"expirationDate"`
+	Tags           []string            `json:"tags"`
+	ID             uuid.UUID           `json:"id"`
+	UserID         *uuid.UUID          `json:"userId"`
+}
+
+// price and initial_quantity are left alone so spend is still counted per
+// purchase
+func (q *Queries) MergeUserItemEntry(ctx context.Context, arg MergeUserItemEntryParams) (Useritementry, error) {
+	row := q.db.QueryRow(ctx, mergeUserItemEntry,
+		arg.Quantity,
+		arg.UnitCost,
+		arg.ExpirationDate,
+		arg.Tags,
+		arg.ID,
+		arg.UserID,
+	)
+	var i Useritementry
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.IngredientID,
+		&i.Quantity,
+		&i.Price,
+		&i.ExpirationDate,
+		&i.LastModified,
+		&i.Deleted,
+		&i.DateCreated,
+		&i.InitialQuantity,
+		&i.LocationID,
+		&i.EnteredUnit,
+		&i.Brand,
+		&i.Store,
+		&i.PurchaseDate,
+		&i.Note,
+		&i.Tags,
+		&i.OpenedAt,
+		&i.RecipeID,
+		&i.StorageLoc,
+		&i.UnitCost,
+	)
+	return i, err
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"pantree/api/db"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deviceIdHeader = "X-Device-Id"
+
+// who made a change to an entry and through which endpoint
+type entryChangeSource struct {
+	ActorID  uuid.UUID
+	DeviceID pgtype.Text
+	Source   string
+}
+
+func changeSourceFromContext(c *gin.Context, actorUuid uuid.UUID) entryChangeSource {
+	source := entryChangeSource{
+		ActorID: actorUuid,
+		Source:  c.FullPath(),
+	}
+
+	if device := c.GetHeader(deviceIdHeader); device != "" {
+		source.DeviceID = getPgtypeText(device)
+	}
+
+	return source
+}
+
+// picks the history action for a change from old to new, old being nil for
+// newly created entries
+func entryActionFor(old *db.Useritementry, new *db.Useritementry) db.EntryAction {
+	switch {
+	case old == nil:
+		return db.EntryActionCreate
+	case new.Deleted && !old.Deleted:
+		return db.EntryActionDelete
+	default:
+		return db.EntryActionUpdate
+	}
+}
+
+func snapshotEntry(entry *db.Useritementry) (json.RawMessage, error) {
+	if entry == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(entry)
+}
+
+// recordEntryChange appends a history row for a change to a user item entry.
+// Run it with the same queries (transaction) as the change itself.
+func recordEntryChange(ctx context.Context, qtx *db.Queries, source entryChangeSource, action db.EntryAction, old *db.Useritementry, new *db.Useritementry) error {
+	oldValue, err := snapshotEntry(old)
+	if err != nil {
+		return err
+	}
+
+	newValue, err := snapshotEntry(new)
+	if err != nil {
+		return err
+	}
+
+	return qtx.CreateEntryHistory(ctx, db.CreateEntryHistoryParams{
+		EntryID:  new.ID,
+		UserID:   new.UserID,
+		ActorID:  &source.ActorID,
+		Action:   action,
+		OldValue: oldValue,
+		NewValue: newValue,
+		DeviceID: source.DeviceID,
+		Source:   source.Source,
+	})
+}
+
+/**
+ * /entryHistory
+ */
+func _handleGetEntryHistory(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	id, err := uuid.Parse(c.Query("id"))
+
+	if err != nil {
+		sendError(c, 400, err, "Invalid user item id.")
+		return
+	}
+
+	history, err := queries.GetEntryHistory(c, db.GetEntryHistoryParams{
+		EntryID: id,
+		UserID:  &userUuid,
+	})
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get user item history.")
+		return
+	}
+
+	if history == nil {
+		history = []db.Useritementryhistory{}
+	}
+
+	c.JSON(200, history)
+}
+
+/**
+ * /history
+ */
+const (
+	defaultHistoryLimit = 50
+	maxHistoryLimit     = 500
+)
+
+func _handleGetUserHistory(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	limit := defaultHistoryLimit
+	if param := c.Query("limit"); param != "" {
+		limit, err = strconv.Atoi(param)
+
+		if err != nil || limit <= 0 || limit > maxHistoryLimit {
+			sendError(c, 400, fmt.Errorf("invalid limit %q", param), fmt.Sprintf("limit must be between 1 and %d.", maxHistoryLimit))
+			return
+		}
+	}
+
+	history, err := queries.GetUserEntryHistory(c, db.GetUserEntryHistoryParams{
+		UserID: &userUuid,
+		Limit:  int32(limit),
+	})
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get pantry history.")
+		return
+	}
+
+	if history == nil {
+		history = []db.Useritementryhistory{}
+	}
+
+	c.JSON(200, history)
+}
+
+/**
+ * /restoreEntry
+ */
+type RestoreEntryRequest struct {
+	HistoryID uuid.UUID `json:"historyId" binding:"required"`
+}
+
+// puts an entry back to how it was right after the given history record
+func _handleRestoreEntry(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request RestoreEntryRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	record, err := queries.GetEntryHistoryRecord(c, db.GetEntryHistoryRecordParams{
+		ID:     request.HistoryID,
+		UserID: &userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, 404, err, "History record not found.")
+		return
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get history record.")
+		return
+	}
+
+	var version db.Useritementry
+	if err := json.Unmarshal(record.NewValue, &version); err != nil {
+		sendError(c, 500, err, "Could not read history record.")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+
+	current, err := qtx.GetUserItemEntry(ctx, db.GetUserItemEntryParams{
+		ID:     record.EntryID,
+		UserID: &userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, 404, err, "User item not found.")
+		return
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get user item.")
+		return
+	}
+
+	log.Printf("Restoring user item %s for user %s from history %s\n", record.EntryID, userUuid, record.ID)
+
+	restored, err := qtx.RestoreUserItemEntry(ctx, db.RestoreUserItemEntryParams{
+		IngredientID:    version.IngredientID,
+		RecipeID:        version.RecipeID,
+		Quantity:        version.Quantity,
+		InitialQuantity: version.InitialQuantity,
+		Price:           version.Price,
+		UnitCost:        version.UnitCost,
+		ExpirationDate:  version.ExpirationDate,
+		LocationID:      version.LocationID,
+		StorageLoc:      version.StorageLoc,
+		EnteredUnit:     version.EnteredUnit,
+		Brand:           version.Brand,
+		Store:           version.Store,
+		PurchaseDate:    version.PurchaseDate,
+		Note:            version.Note,
+		Tags:            version.Tags,
+		OpenedAt:        version.OpenedAt,
+		Deleted:         version.Deleted,
+		ID:              record.EntryID,
+		UserID:          &userUuid,
+	})
+
+	if err != nil {
+		sendError(c, 500, err, "Could not restore user item.")
+		return
+	}
+
+	// whatever was used up or thrown out after this version is back in the
+	// pantry, so it must not count as waste or consumption any more
+	err = qtx.DeleteUserItemDisposalsSince(ctx, db.DeleteUserItemDisposalsSinceParams{
+		EntryID: record.EntryID,
+		UserID:  &userUuid,
+		Since:   record.DateCreated,
+	})
+
+	if err != nil {
+		sendError(c, 500, err, "Could not undo user item disposals.")
+		return
+	}
+
+	err = recordEntryChange(ctx, qtx, changeSourceFromContext(c, userUuid), db.EntryActionRestore, &current, &restored)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not record user item history.")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	c.JSON(200, restored)
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"pantree/api/db"
+	"pantree/api/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// entries whose expiration dates are this close are treated as the same stock
+const mergeExpiryTolerance = 24 * time.Hour
+
+func sameOptionalUuid(a *uuid.UUID, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// canMergeEntries reports whether two entries are the same stock: the same
+// ingredient (or recipe, for leftovers), brand, store and note in the same
+// location, sealed, expiring around the same time
+func canMergeEntries(a db.Useritementry, b db.Useritementry) bool {
+	return sameOptionalUuid(a.IngredientID, b.IngredientID) &&
+		sameOptionalUuid(a.RecipeID, b.RecipeID) &&
+		a.StorageLoc == b.StorageLoc &&
+		sameOptionalUuid(a.LocationID, b.LocationID) &&
+		a.Brand == b.Brand &&
+		a.Store == b.Store &&
+		a.Note == b.Note &&
+		a.OpenedAt == nil && b.OpenedAt == nil &&
+		models.ExpiresTogether(a.ExpirationDate, b.ExpirationDate, mergeExpiryTolerance)
+}
+
+func userMergesDuplicates(ctx context.Context, qtx *db.Queries, userUuid uuid.UUID) (bool, error) {
+	user, err := qtx.GetUser(ctx, db.GetUserParams{ID: &userUuid})
+	if err != nil {
+		return false, err
+	}
+	return user.MergeDuplicates, nil
+}
+
+// findMergeTarget locks and returns the oldest live entry entry could be merged
+// into, or nil when there is none
+func findMergeTarget(ctx context.Context, qtx *db.Queries, userUuid uuid.UUID, entry db.Useritementry) (*db.Useritementry, error) {
+	if entry.IngredientID == nil {
+		return nil, nil
+	}
+
+	candidates, err := qtx.GetMergeCandidates(ctx, db.GetMergeCandidatesParams{
+		UserID:       &userUuid,
+		IngredientID: entry.IngredientID,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, candidate := range candidates {
+		if candidate.ID != entry.ID && canMergeEntries(candidate, entry) {
+			return &candidate, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func entryStock(entry db.Useritementry) models.PricedStock {
+	return models.PricedStock{
+		Quantity: entry.Quantity,
+		Initial:  entry.InitialQuantity,
+		Price:    entry.Price,
+		UnitCost: entry.UnitCost,
+	}
+}
+
+// mergeUserItems moves the stock of parts onto target, keeping the earliest
+// expiration date and every tag. The target's price and initial quantity stay
+// the record of its own purchase, what is left is costed by unit_cost instead.
+// Parts still need removing with removeMergedUserItem.
+func mergeUserItems(ctx context.Context, qtx *db.Queries, source entryChangeSource, target db.Useritementry, parts []db.Useritementry) (db.Useritementry, error) {
+	quantity := target.Quantity
+	expiration := target.ExpirationDate
+	tags := target.Tags
+	stock := []models.PricedStock{entryStock(target)}
+
+	for _, part := range parts {
+		quantity = quantity.Add(part.Quantity)
+		if part.ExpirationDate != nil && (expiration == nil || part.ExpirationDate.Before(*expiration)) {
+			expiration = part.ExpirationDate
+		}
+		tags = append(tags, part.Tags...)
+		stock = append(stock, entryStock(part))
+	}
+
+	merged, err := qtx.MergeUserItemEntry(ctx, db.MergeUserItemEntryParams{
+		Quantity:       quantity,
+		UnitCost:       models.MergedUnitCost(stock),
+		ExpirationDate: expiration,
+		Tags:           normalizeTags(tags),
+		ID:             target.ID,
+		UserID:         target.UserID,
+	})
+
+	if err != nil {
+		return merged, err
+	}
+
+	return merged, recordEntryChange(ctx, qtx, source, db.EntryActionUpdate, &target, &merged)
+}
+
+// removeMergedUserItem soft-deletes an entry that was merged into another one.
+// Its stock moved rather than left the pantry, so no disposal is recorded, and
+// the row stays behind as the record of what was paid and when.
+func removeMergedUserItem(ctx context.Context, qtx *db.Queries, source entryChangeSource, entry db.Useritementry) (db.Useritementry, error) {
+	deleted, err := qtx.DeleteUserItemEntry(ctx, db.DeleteUserItemEntryParams{
+		ID:     entry.ID,
+		UserID: entry.UserID,
+	})
+
+	if err != nil {
+		return deleted, err
+	}
+
+	return deleted, recordEntryChange(ctx, qtx, source, db.EntryActionDelete, &entry, &deleted)
+}
+
+// createOrMergeUserItem creates an entry, with a suggested expiration date
+// when it has none, and, when the user opted into merging duplicates, moves it
+// onto a matching one. Either way the new row is kept as the purchase record.
+func createOrMergeUserItem(ctx context.Context, qtx *db.Queries, source entryChangeSource, params db.CreateUserItemEntryParams) (db.Useritementry, error) {
+	if params.ExpirationDate == nil && params.UserID != nil {
+		expiration, err := defaultExpiration(ctx, qtx, *params.UserID, params.IngredientID, params.LocationID, params.StorageLoc, params.PurchaseDate)
+		if err != nil {
+			return db.Useritementry{}, err
+		}
+		params.ExpirationDate = expiration
+	}
+
+	item, err := qtx.CreateUserItemEntry(ctx, params)
+	if err != nil {
+		return item, err
+	}
+
+	if err := recordEntryChange(ctx, qtx, source, db.EntryActionCreate, nil, &item); err != nil {
+		return item, err
+	}
+
+	if item.UserID == nil {
+		return item, nil
+	}
+
+	merge, err := userMergesDuplicates(ctx, qtx, *item.UserID)
+	if err != nil || !merge {
+		return item, err
+	}
+
+	target, err := findMergeTarget(ctx, qtx, *item.UserID, item)
+	if err != nil || target == nil {
+		return item, err
+	}
+
+	merged, err := mergeUserItems(ctx, qtx, source, *target, []db.Useritementry{item})
+	if err != nil {
+		return merged, err
+	}
+
+	if _, err := removeMergedUserItem(ctx, qtx, source, item); err != nil {
+		return merged, err
+	}
+
+	return merged, nil
+}
+
+/**
+ * /compact
+ */
+type CompactResponse struct {
+	Merged  []db.Useritementry `json:"merged"`
+	Removed []db.Useritementry `json:"removed"`
+}
+
+// merges every group of duplicate entries into its oldest entry, whether or
+// not the user opted into merging new ones
+func _handleCompactPantry(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+
+	entries, err := qtx.GetCompactableUserItemEntries(ctx, &userUuid)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get user items.")
+		return
+	}
+
+	// entries come oldest first, so each group starts with the one to keep
+	var groups [][]db.Useritementry
+	for _, entry := range entries {
+		grouped := false
+		for i, group := range groups {
+			if canMergeEntries(group[0], entry) {
+				groups[i] = append(group, entry)
+				grouped = true
+				break
+			}
+		}
+
+		if !grouped {
+			groups = append(groups, []db.Useritementry{entry})
+		}
+	}
+
+	source := changeSourceFromContext(c, userUuid)
+	response := CompactResponse{
+		Merged:  []db.Useritementry{},
+		Removed: []db.Useritementry{},
+	}
+
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+
+		merged, err := mergeUserItems(ctx, qtx, source, group[0], group[1:])
+
+		if err != nil {
+			sendError(c, 500, err, "Could not merge user items.")
+			return
+		}
+
+		response.Merged = append(response.Merged, merged)
+
+		for _, entry := range group[1:] {
+			removed, err := removeMergedUserItem(ctx, qtx, source, entry)
+
+			if err != nil {
+				sendError(c, 500, err, "Could not remove merged user item.")
+				return
+			}
+
+			response.Removed = append(response.Removed, removed)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	c.JSON(200, response)
+}
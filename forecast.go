@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"pantree/api/db"
+	"pantree/api/models"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// how far back consumption is averaged over
+const forecastLookback = 60 * 24 * time.Hour
+
+const defaultForecastDays = 7
+
+type ingredientForecast struct {
+	IngredientID   uuid.UUID       `json:"ingredientId"`
+	IngredientName string          `json:"ingredientName"`
+	Unit           db.UnitType     `json:"unit"`
+	OnHand         decimal.Decimal `json:"onHand"`
+	// ground truth units used per day
+	DailyUsage decimal.Decimal `json:"dailyUsage"`
+	RunOutDate *time.Time      `json:"runOutDate"`
+}
+
+// getConsumptionForecasts predicts when each ingredient the user has on hand
+// runs out, from how fast it was used over the lookback window. The pantry
+// rows have to cover every location so the on hand totals are complete.
+// Ingredients that have not been used have no run out date.
+func getConsumptionForecasts(ctx context.Context, userUuid uuid.UUID, pantry []db.Userpantryview) (map[uuid.UUID]ingredientForecast, error) {
+	now := time.Now()
+
+	history, err := queries.GetConsumptionHistory(ctx, db.GetConsumptionHistoryParams{
+		UserID: &userUuid,
+		Since:  now.Add(-forecastLookback),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	forecasts := map[uuid.UUID]ingredientForecast{}
+	for _, item := range pantry {
+		if item.IngredientID == nil {
+			continue
+		}
+
+		forecast := forecasts[*item.IngredientID]
+		forecast.IngredientID = *item.IngredientID
+		forecast.IngredientName = item.IngredientName
+		forecast.Unit = item.Unit
+		forecast.OnHand = forecast.OnHand.Add(item.Quantity)
+		forecasts[*item.IngredientID] = forecast
+	}
+
+	for _, row := range history {
+		forecast, ok := forecasts[row.IngredientID]
+		if !ok {
+			continue
+		}
+
+		forecast.DailyUsage = models.DailyUsage(row.Consumed, row.ObservedSince, now)
+		forecast.RunOutDate = models.RunOutDate(forecast.OnHand, forecast.DailyUsage, now)
+		forecasts[row.IngredientID] = forecast
+	}
+
+	return forecasts, nil
+}
+
+// getPantryForecasts forecasts against pantry rows that were already loaded
+// with filter, only loading the whole pantry again when the filter left some
+// of it out
+func getPantryForecasts(ctx context.Context, userUuid uuid.UUID, filter db.FilterUserPantryParams, pantry []db.Userpantryview) (map[uuid.UUID]ingredientForecast, error) {
+	if filter != (db.FilterUserPantryParams{UserID: filter.UserID, Sort: filter.Sort}) {
+		var err error
+		pantry, err = queries.FilterUserPantry(ctx, db.FilterUserPantryParams{
+			UserID: userUuid,
+			Sort:   "name",
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return getConsumptionForecasts(ctx, userUuid, pantry)
+}
+
+/**
+ * /forecast
+ */
+// ingredients predicted to run out within the given number of days (default
+// 7), soonest first
+func _handleGetForecast(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	days := defaultForecastDays
+	if param := c.Query("days"); param != "" {
+		days, err = strconv.Atoi(param)
+
+		if err != nil || days < 0 {
+			sendError(c, 400, fmt.Errorf("invalid days %q", param), "days must be a number of days.")
+			return
+		}
+	}
+
+	pantry, err := queries.FilterUserPantry(c, db.FilterUserPantryParams{
+		UserID: userUuid,
+		Sort:   "name",
+	})
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get pantry.")
+		return
+	}
+
+	forecasts, err := getConsumptionForecasts(c, userUuid, pantry)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not forecast pantry usage.")
+		return
+	}
+
+	before := time.Now().AddDate(0, 0, days)
+	runningOut := []ingredientForecast{}
+	for _, forecast := range forecasts {
+		if forecast.RunOutDate != nil && forecast.RunOutDate.Before(before) {
+			runningOut = append(runningOut, forecast)
+		}
+	}
+
+	sort.Slice(runningOut, func(i, j int) bool {
+		return runningOut[i].RunOutDate.Before(*runningOut[j].RunOutDate)
+	})
+
+	c.JSON(200, runningOut)
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"pantree/api/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type HouseholdResponse struct {
+	db.Household
+	Members []db.GetHouseholdMembersRow `json:"members"`
+}
+
+// responds with 403 and returns false when the user is not in the household
+func _requireHouseholdMember(c *gin.Context, userUuid uuid.UUID, householdId uuid.UUID) bool {
+	isMember, err := queries.IsHouseholdMember(c, db.IsHouseholdMemberParams{
+		HouseholdID: householdId,
+		UserID:      userUuid,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not check household membership")
+		return false
+	}
+
+	if !isMember {
+		sendError(c, http.StatusForbidden, fmt.Errorf("user %s is not in household %s", userUuid, householdId), "Not a member of this household")
+		return false
+	}
+
+	return true
+}
+
+/**
+ * /get
+ */
+func getHouseholds(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	households, err := queries.GetUserHouseholds(c, userUuid)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not fetch households")
+		return
+	}
+
+	response := make([]HouseholdResponse, len(households))
+	for i, household := range households {
+		members, err := queries.GetHouseholdMembers(c, household.ID)
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, err, "Could not fetch household members")
+			return
+		}
+
+		if members == nil {
+			members = []db.GetHouseholdMembersRow{}
+		}
+
+		response[i] = HouseholdResponse{Household: household, Members: members}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+/**
+ * /create
+ */
+type CreateHouseholdRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func createHousehold(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	var request CreateHouseholdRequest
+	if err := c.BindJSON(&request); err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid request body")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Failed to start transaction")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+
+	household, err := qtx.CreateHousehold(ctx, db.CreateHouseholdParams{
+		CreatorID: &userUuid,
+		Name:      request.Name,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not create household")
+		return
+	}
+
+	err = qtx.AddHouseholdMember(ctx, db.AddHouseholdMemberParams{
+		HouseholdID: household.ID,
+		UserID:      userUuid,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not join household")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Transaction failed")
+		return
+	}
+
+	log.Printf("User %s created household %s\n", userUuid, household.ID)
+	c.JSON(http.StatusOK, household)
+}
+
+/**
+ * /addMember
+ */
+type AddHouseholdMemberRequest struct {
+	HouseholdID uuid.UUID `json:"householdId" binding:"required"`
+	Email       string    `json:"email" binding:"required,email"`
+}
+
+func addHouseholdMember(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	var request AddHouseholdMemberRequest
+	if err := c.BindJSON(&request); err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid request body")
+		return
+	}
+
+	if !_requireHouseholdMember(c, userUuid, request.HouseholdID) {
+		return
+	}
+
+	member, err := queries.GetUser(c, db.GetUserParams{
+		Email: pgtype.Text{String: request.Email, Valid: true},
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, http.StatusNotFound, err, "No user with that email")
+		return
+	}
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get user")
+		return
+	}
+
+	err = queries.AddHouseholdMember(c, db.AddHouseholdMemberParams{
+		HouseholdID: request.HouseholdID,
+		UserID:      member.ID,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not add household member")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member added"})
+}
+
+/**
+ * /leave
+ */
+type LeaveHouseholdRequest struct {
+	HouseholdID uuid.UUID `json:"householdId" binding:"required"`
+}
+
+func leaveHousehold(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	var request LeaveHouseholdRequest
+	if err := c.BindJSON(&request); err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid request body")
+		return
+	}
+
+	err = queries.RemoveHouseholdMember(c, db.RemoveHouseholdMemberParams{
+		HouseholdID: request.HouseholdID,
+		UserID:      userUuid,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not leave household")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left household"})
+}
+
+func registerHouseholdRoutes(router *gin.RouterGroup) {
+	router.GET("/get", getHouseholds)
+	router.POST("/create", createHousehold)
+	router.POST("/addMember", addHouseholdMember)
+	router.POST("/leave", leaveHousehold)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -14,8 +15,15 @@ import (
  * /getIngredients
  */
 func _handleGetIngredients(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
 	log.Println("Getting ingredients")
-	ingredients, err := queries.GetIngredients(c)
+	ingredients, err := queries.GetIngredients(c, userUuid)
 
 	if err != nil {
 		log.Println("Could not get ingredients:", err)
@@ -40,6 +48,55 @@ type NewIngredientRequest struct {
 	StorageLoc     db.LocType  `json:"storageLoc" binding:"required"`
 	IngredientType db.GrocType `json:"ingredientType" binding:"required"`
 	ImagePath      string      `json:"imagePath"`
+	// private (default), household or, for admins, global
+	Visibility  db.VisibilityType `json:"visibility"`
+	HouseholdID *uuid.UUID        `json:"householdId"`
+	// days the ingredient keeps once opened
+	OpenedShelfLifeDays *int32 `json:"openedShelfLifeDays"`
+}
+
+// reads an optional number of shelf life days, responding with 400 and
+// returning false when it is not positive
+func _parseShelfLifeDays(c *gin.Context, days *int32) (pgtype.Int4, bool) {
+	if days == nil {
+		return pgtype.Int4{}, true
+	}
+
+	if *days <= 0 {
+		sendError(c, 400, fmt.Errorf("shelf life of %d days", *days), "Shelf life must be a positive number of days.")
+		return pgtype.Int4{}, false
+	}
+
+	return pgtype.Int4{Int32: *days, Valid: true}, true
+}
+
+// checks that the user may give an ingredient the requested visibility,
+// responding with an error and returning false otherwise
+func _checkIngredientVisibility(c *gin.Context, userUuid uuid.UUID, visibility db.VisibilityType, householdId *uuid.UUID) bool {
+	switch visibility {
+	case db.VisibilityTypePrivate:
+		return true
+	case db.VisibilityTypeHousehold:
+		if householdId == nil {
+			sendError(c, 400, fmt.Errorf("householdId is required"), "Household ingredients need a household.")
+			return false
+		}
+		return _requireHouseholdMember(c, userUuid, *householdId)
+	case db.VisibilityTypeGlobal:
+		admin, err := isAdmin(c, userUuid)
+		if err != nil {
+			sendError(c, 500, err, "Could not get user.")
+			return false
+		}
+		if !admin {
+			sendError(c, 403, fmt.Errorf("user %s is not an admin", userUuid), "Propose the ingredient for promotion to make it global.")
+			return false
+		}
+		return true
+	}
+
+	sendError(c, 400, fmt.Errorf("invalid visibility %q", visibility), "Invalid visibility.")
+	return false
 }
 
 func _handleNewIngredient(c *gin.Context) {
@@ -58,15 +115,35 @@ func _handleNewIngredient(c *gin.Context) {
 		return
 	}
 
+	if request.Visibility == "" {
+		request.Visibility = db.VisibilityTypePrivate
+	}
+
+	if !_checkIngredientVisibility(c, userUuid, request.Visibility, request.HouseholdID) {
+		return
+	}
+
+	if request.Visibility != db.VisibilityTypeHousehold {
+		request.HouseholdID = nil
+	}
+
+	openedShelfLife, ok := _parseShelfLifeDays(c, request.OpenedShelfLifeDays)
+	if !ok {
+		return
+	}
+
 	log.Printf("Creating new ingredient for user %s", userUuid)
 
 	newIngredient, err := queries.CreateIngredient(c, db.CreateIngredientParams{
-		CreatorID:      &userUuid,
-		Name:           request.Name,
-		Unit:           request.Unit,
-		StorageLoc:     request.StorageLoc,
-		IngredientType: request.IngredientType,
-		ImagePath:      getPgtypeText(request.ImagePath),
+		CreatorID:           &userUuid,
+		Name:                normalizeIngredientName(request.Name),
+		Unit:                request.Unit,
+		StorageLoc:          request.StorageLoc,
+		IngredientType:      request.IngredientType,
+		ImagePath:           getPgtypeText(request.ImagePath),
+		Visibility:          request.Visibility,
+		HouseholdID:         request.HouseholdID,
+		OpenedShelfLifeDays: openedShelfLife,
 	})
 
 	if err != nil {
@@ -89,6 +166,13 @@ type GetIngredientsByIdsRequest struct {
 }
 
 func _handleGetIngredientsByIds(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
 	var request GetIngredientsByIdsRequest
 	if err := c.BindJSON(&request); err != nil {
 		log.Println("Invalid request body: \n", err)
@@ -108,7 +192,10 @@ func _handleGetIngredientsByIds(c *gin.Context) {
 		uuids[i] = uuid
 	}
 
-	ingredients, err := queries.GetIngredientsByIds(c, uuids)
+	ingredients, err := queries.GetVisibleIngredientsByIds(c, db.GetVisibleIngredientsByIdsParams{
+		Ids:    uuids,
+		UserID: userUuid,
+	})
 
 	if err != nil {
 		log.Println("Could not get ingredients by ids:", err)
@@ -131,6 +218,13 @@ type SearchIngredientsRequest struct {
 }
 
 func _handleSearchIngredients(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
 	var request SearchIngredientsRequest
 	if err := c.BindJSON(&request); err != nil {
 		log.Println("Invalid request body: \n", err)
@@ -139,7 +233,10 @@ func _handleSearchIngredients(c *gin.Context) {
 	}
 
 	log.Println("Searching ingredients")
-	ingredients, err := queries.SearchIngredients(c, pgtype.Text{String: request.Name, Valid: true})
+	ingredients, err := queries.SearchIngredients(c, db.SearchIngredientsParams{
+		Name:   pgtype.Text{String: request.Name, Valid: true},
+		UserID: userUuid,
+	})
 
 	if err != nil {
 		log.Println("Could not search ingredients:", err)
@@ -154,9 +251,509 @@ func _handleSearchIngredients(c *gin.Context) {
 	c.JSON(200, ingredients)
 }
 
+// loads an ingredient the caller is allowed to modify, responding with the
+// appropriate error and returning false otherwise
+func _getEditableIngredient(c *gin.Context, userUuid uuid.UUID, id uuid.UUID) (db.Ingredient, bool) {
+	ingredient, err := queries.GetIngredient(c, id)
+
+	if err == pgx.ErrNoRows || (err == nil && ingredient.Deleted) {
+		sendError(c, 404, fmt.Errorf("ingredient %s not found", id), "Ingredient not found.")
+		return ingredient, false
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get ingredient.")
+		return ingredient, false
+	}
+
+	// once in the global catalog the ingredient belongs to everyone, so only
+	// admins may change it, whoever created it
+	global := ingredient.Visibility == db.VisibilityTypeGlobal
+
+	if !global && ingredient.CreatorID != nil && *ingredient.CreatorID == userUuid {
+		return ingredient, true
+	}
+
+	admin, err := isAdmin(c, userUuid)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get user.")
+		return ingredient, false
+	}
+
+	if !admin && global {
+		sendError(c, 403, fmt.Errorf("ingredient %s is global", id), "Only admins can change catalog ingredients.")
+		return ingredient, false
+	}
+
+	if !admin {
+		sendError(c, 403, fmt.Errorf("user %s does not own ingredient %s", userUuid, id), "Only the creator can change this ingredient.")
+		return ingredient, false
+	}
+
+	return ingredient, true
+}
+
+/**
+ * /update
+ */
+type UpdateIngredientRequest struct {
+	ID             uuid.UUID `json:"id" binding:"required"`
+	Name           string    `json:"name"`
+	Unit           string    `json:"unit"`
+	StorageLoc     string    `json:"storageLoc"`
+	IngredientType string    `json:"ingredientType"`
+	ImagePath      string    `json:"imagePath"`
+	// switching between private and household; global goes through promotion
+	Visibility          string     `json:"visibility"`
+	HouseholdID         *uuid.UUID `json:"householdId"`
+	CategoryID          *uuid.UUID `json:"categoryId"`
+	OpenedShelfLifeDays *int32     `json:"openedShelfLifeDays"`
+}
+
+func _handleUpdateIngredient(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request UpdateIngredientRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	if request.Unit != "" && !isValidUnitType(db.UnitType(request.Unit)) {
+		sendError(c, 400, fmt.Errorf("invalid unit %q", request.Unit), "Invalid unit.")
+		return
+	}
+
+	if request.StorageLoc != "" && !isValidLocType(db.LocType(request.StorageLoc)) {
+		sendError(c, 400, fmt.Errorf("invalid storage location %q", request.StorageLoc), "Invalid storage location.")
+		return
+	}
+
+	if request.IngredientType != "" && !isValidGrocType(db.GrocType(request.IngredientType)) {
+		sendError(c, 400, fmt.Errorf("invalid ingredient type %q", request.IngredientType), "Invalid ingredient type.")
+		return
+	}
+
+	ingredient, ok := _getEditableIngredient(c, userUuid, request.ID)
+	if !ok {
+		return
+	}
+
+	var params = db.UpdateIngredientParams{
+		ID: request.ID,
+	}
+
+	if request.Name != "" {
+		name := normalizeIngredientName(request.Name)
+		if name == "" {
+			sendError(c, 400, fmt.Errorf("name is blank"), "Name cannot be blank.")
+			return
+		}
+		params.Name = getPgtypeText(name)
+	}
+
+	if request.StorageLoc != "" {
+		params.StorageLoc = db.NullLocType{
+			LocType: db.LocType(request.StorageLoc),
+			Valid:   true,
+		}
+	}
+
+	if request.IngredientType != "" {
+		params.IngredientType = db.NullGrocType{
+			GrocType: db.GrocType(request.IngredientType),
+			Valid:    true,
+		}
+	}
+
+	if request.ImagePath != "" {
+		params.ImagePath = getPgtypeText(request.ImagePath)
+	}
+
+	params.CategoryID = request.CategoryID
+
+	if params.OpenedShelfLifeDays, ok = _parseShelfLifeDays(c, request.OpenedShelfLifeDays); !ok {
+		return
+	}
+
+	visibility := db.VisibilityType(request.Visibility)
+	if request.Visibility != "" && !_checkIngredientVisibility(c, userUuid, visibility, request.HouseholdID) {
+		return
+	}
+
+	if visibility != db.VisibilityTypeHousehold {
+		request.HouseholdID = nil
+	}
+
+	log.Printf("Updating ingredient %s for user %s\n", request.ID, userUuid)
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+
+	if request.CategoryID != nil {
+		_, err := qtx.GetIngredientCategory(ctx, *request.CategoryID)
+
+		if err == pgx.ErrNoRows {
+			sendError(c, 400, err, "Unknown ingredient category.")
+			return
+		}
+
+		if err != nil {
+			sendError(c, 500, err, "Could not get ingredient category.")
+			return
+		}
+	}
+
+	// quantities are stored in the ingredient's unit, so changing it would
+	// silently reinterpret everyone else's pantry and recipes
+	changesUnit := request.Unit != "" && db.UnitType(request.Unit) != ingredient.Unit
+
+	// hiding the ingredient from people who could see it would leave their
+	// entries and recipes pointing at one they cannot
+	narrowsVisibility := request.Visibility != "" &&
+		ingredient.Visibility != db.VisibilityTypePrivate &&
+		visibility != db.VisibilityTypeGlobal &&
+		(visibility != ingredient.Visibility || !sameOptionalUuid(request.HouseholdID, ingredient.HouseholdID))
+
+	if changesUnit || narrowsVisibility {
+		refs, err := qtx.CountIngredientReferences(ctx, db.CountIngredientReferencesParams{
+			ID:     request.ID,
+			UserID: userUuid,
+		})
+
+		if err != nil {
+			sendError(c, 500, err, "Could not check ingredient usage.")
+			return
+		}
+
+		if refs.OtherReferences > 0 {
+			message := "Cannot hide an ingredient other users rely on."
+			if changesUnit {
+				message = "Cannot change the unit of an ingredient other users rely on."
+			}
+
+			sendError(c, 409, fmt.Errorf("ingredient %s is used by %d other entries", request.ID, refs.OtherReferences), message)
+			return
+		}
+	}
+
+	if changesUnit {
+		params.Unit = db.NullUnitType{
+			UnitType: db.UnitType(request.Unit),
+			Valid:    true,
+		}
+	}
+
+	updated, err := qtx.UpdateIngredient(ctx, params)
+
+	if isUniqueViolation(err) {
+		sendError(c, 409, err, "A catalog ingredient with this name already exists.")
+		return
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not update ingredient.")
+		return
+	}
+
+	if request.Visibility != "" {
+		updated, err = qtx.SetIngredientVisibility(ctx, db.SetIngredientVisibilityParams{
+			Visibility:  visibility,
+			HouseholdID: request.HouseholdID,
+			ID:          request.ID,
+		})
+
+		if err != nil {
+			sendError(c, 500, err, "Could not change ingredient visibility.")
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	c.JSON(200, updated)
+}
+
+/**
+ * /delete
+ */
+type DeleteIngredientRequest struct {
+	ID uuid.UUID `json:"id" binding:"required"`
+}
+
+func _handleDeleteIngredient(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request DeleteIngredientRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	if _, ok := _getEditableIngredient(c, userUuid, request.ID); !ok {
+		return
+	}
+
+	// the row is kept as a tombstone so existing recipes and pantry entries
+	// stay valid and sync clients see the deletion through last_modified
+	log.Printf("Deleting ingredient %s\n", request.ID)
+	deleted, err := queries.SoftDeleteIngredient(c, request.ID)
+
+	if err == pgx.ErrNoRows {
+		sendError(c, 404, err, "Ingredient not found.")
+		return
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not delete ingredient.")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"message":    "Ingredient deleted.",
+		"ingredient": deleted,
+	})
+}
+
+/**
+ * /proposePromotion
+ */
+type ProposePromotionRequest struct {
+	IngredientID uuid.UUID `json:"ingredientId" binding:"required"`
+	Note         string    `json:"note"`
+}
+
+func _handleProposePromotion(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request ProposePromotionRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	ingredient, ok := _getEditableIngredient(c, userUuid, request.IngredientID)
+	if !ok {
+		return
+	}
+
+	if ingredient.Visibility == db.VisibilityTypeGlobal {
+		sendError(c, 409, fmt.Errorf("ingredient %s is already global", ingredient.ID), "Ingredient is already in the global catalog.")
+		return
+	}
+
+	var note pgtype.Text
+	if request.Note != "" {
+		note = getPgtypeText(request.Note)
+	}
+
+	promotion, err := queries.CreateIngredientPromotion(c, db.CreateIngredientPromotionParams{
+		IngredientID: request.IngredientID,
+		RequesterID:  &userUuid,
+		Note:         note,
+	})
+
+	if isUniqueViolation(err) {
+		sendError(c, 409, err, "A promotion request for this ingredient is already pending.")
+		return
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not propose ingredient.")
+		return
+	}
+
+	log.Printf("User %s proposed ingredient %s for the catalog\n", userUuid, request.IngredientID)
+	c.JSON(200, promotion)
+}
+
+/**
+ * /admin/ingredientPromotions
+ */
+func _handleGetIngredientPromotions(c *gin.Context) {
+	promotions, err := queries.GetPendingIngredientPromotions(c)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get promotion requests.")
+		return
+	}
+
+	if promotions == nil {
+		promotions = []db.Ingredientpromotion{}
+	}
+
+	c.JSON(200, promotions)
+}
+
+/**
+ * /admin/reviewIngredientPromotion
+ */
+type ReviewPromotionRequest struct {
+	ID      uuid.UUID `json:"id" binding:"required"`
+	Approve bool      `json:"approve"`
+}
+
+func _handleReviewIngredientPromotion(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request ReviewPromotionRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	status := db.PromotionStatusRejected
+	if request.Approve {
+		status = db.PromotionStatusApproved
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+
+	promotion, err := qtx.ReviewIngredientPromotion(ctx, db.ReviewIngredientPromotionParams{
+		Status:     status,
+		ReviewerID: &userUuid,
+		ID:         request.ID,
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, 404, err, "No pending promotion request with that id.")
+		return
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not review promotion request.")
+		return
+	}
+
+	if request.Approve {
+		_, err = qtx.SetIngredientVisibility(ctx, db.SetIngredientVisibilityParams{
+			Visibility: db.VisibilityTypeGlobal,
+			ID:         promotion.IngredientID,
+		})
+
+		// another catalog ingredient already has this name
+		if isUniqueViolation(err) {
+			sendError(c, 409, err, "The catalog already has an ingredient with this name.")
+			return
+		}
+
+		if err != nil {
+			sendError(c, 500, err, "Could not promote ingredient.")
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	log.Printf("Promotion %s %s by %s\n", promotion.ID, promotion.Status, userUuid)
+	c.JSON(200, promotion)
+}
+
+/**
+ * /categories
+ */
+func _handleGetIngredientCategories(c *gin.Context) {
+	categories, err := queries.GetIngredientCategories(c)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get ingredient categories.")
+		return
+	}
+
+	if categories == nil {
+		categories = []db.Ingredientcategory{}
+	}
+
+	c.JSON(200, categories)
+}
+
+/**
+ * /admin/newIngredientCategory
+ */
+type NewIngredientCategoryRequest struct {
+	Name     string     `json:"name" binding:"required"`
+	ParentID *uuid.UUID `json:"parentId"`
+}
+
+func _handleNewIngredientCategory(c *gin.Context) {
+	var request NewIngredientCategoryRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	category, err := queries.CreateIngredientCategory(c, db.CreateIngredientCategoryParams{
+		ParentID: request.ParentID,
+		Name:     normalizeIngredientName(request.Name),
+	})
+
+	if err != nil {
+		sendError(c, 500, err, "Could not create ingredient category.")
+		return
+	}
+
+	c.JSON(200, category)
+}
+
 func registerIngredientsRoutes(router *gin.RouterGroup) {
 	router.GET("/ingredients", _handleGetIngredients)
 	router.POST("/ingredientsByIds", _handleGetIngredientsByIds)
 	router.POST("/searchIngredients", _handleSearchIngredients)
 	router.POST("/newIngredient", _handleNewIngredient)
+	router.POST("/update", _handleUpdateIngredient)
+	router.POST("/delete", _handleDeleteIngredient)
+	router.POST("/proposePromotion", _handleProposePromotion)
+	router.GET("/categories", _handleGetIngredientCategories)
 }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"pantree/api/db"
+	"pantree/api/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// how long leftovers keep when no expiration date is given
+var leftoverShelfLife = map[db.LocType]time.Duration{
+	db.LocTypeFridge:  4 * 24 * time.Hour,
+	db.LocTypeFreezer: 90 * 24 * time.Hour,
+}
+
+/**
+ * /addLeftovers
+ */
+type AddLeftoversRequest struct {
+	RecipeID uuid.UUID       `json:"recipeId" binding:"required"`
+	Portions decimal.Decimal `json:"portions" binding:"required"`
+	// fridge (default) or freezer
+	StorageLoc db.LocType `json:"storageLoc"`
+	// ms since epoch, defaults to the storage's leftover shelf life
+	ExpirationDate *float64 `json:"expirationDate"`
+}
+
+func _handleAddLeftovers(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request AddLeftoversRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	if !request.Portions.IsPositive() {
+		sendError(c, 400, fmt.Errorf("portions %s is not positive", request.Portions), "Portions must be positive.")
+		return
+	}
+
+	if request.StorageLoc == "" {
+		request.StorageLoc = db.LocTypeFridge
+	}
+
+	shelfLife, ok := leftoverShelfLife[request.StorageLoc]
+	if !ok {
+		sendError(c, 400, fmt.Errorf("leftovers in %q", request.StorageLoc), "Leftovers go in the fridge or freezer.")
+		return
+	}
+
+	recipe, err := queries.GetRecipe(c, request.RecipeID)
+
+	if err == pgx.ErrNoRows {
+		sendError(c, 404, err, "Recipe not found.")
+		return
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get recipe.")
+		return
+	}
+
+	// portions are stored like any other count
+	quantity, _, err := models.ToBase(request.Portions, models.QuantityCount)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not convert portions.")
+		return
+	}
+
+	expiration := time.Now().Add(shelfLife)
+	if request.ExpirationDate != nil {
+		expiration = *msToTime(request.ExpirationDate)
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+
+	item, err := qtx.CreateLeftoverEntry(ctx, db.CreateLeftoverEntryParams{
+		UserID:         &userUuid,
+		RecipeID:       &recipe.ID,
+		Quantity:       quantity,
+		ExpirationDate: &expiration,
+		StorageLoc:     db.NullLocType{LocType: request.StorageLoc, Valid: true},
+	})
+
+	if err != nil {
+		sendError(c, 500, err, "Could not create leftovers.")
+		return
+	}
+
+	err = recordEntryChange(ctx, qtx, changeSourceFromContext(c, userUuid), db.EntryActionCreate, nil, &item)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not record user item history.")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	log.Printf("Added %s portions of %s leftovers for user %s\n", request.Portions, recipe.ID, userUuid)
+	c.JSON(200, item)
+}
@@ -32,6 +32,14 @@ func bing(c *gin.Context) {
 	})
 }
 
+func runCommand(args []string) error {
+	switch args[0] {
+	case "seed":
+		return seedCommand(args[1:])
+	}
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
 func main() {
 	// read config
 	readConfig()
@@ -58,6 +66,14 @@ func main() {
 
 	queries = db.New(conn)
 
+	// one-off commands, e.g. `pantree-api seed ingredients catalog.csv`
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	router := gin.Default()
 
 	middleware := registerAuth(router)
@@ -69,6 +85,9 @@ func main() {
 	users := api.Group("/users")
 	registerUserRoutes(users)
 
+	households := api.Group("/households")
+	registerHouseholdRoutes(households)
+
 	ingredients := api.Group("/ingredients")
 	registerIngredientsRoutes(ingredients)
 
@@ -78,8 +97,17 @@ func main() {
 	recipes := api.Group("/recipes")
 	registerRecipeRoutes(recipes)
 
+	shopping := api.Group("/shopping")
+	registerShoppingRoutes(shopping)
+
+	analytics := api.Group("/analytics")
+	registerAnalyticsRoutes(analytics)
+
 	sync := api.Group("/sync")
 	registerSyncRoutes(sync)
 
+	admin := api.Group("/admin")
+	registerAdminRoutes(admin)
+
 	router.Run(fmt.Sprintf("%s:%s", cfg.Server.Broadcast, cfg.Server.Port))
 }
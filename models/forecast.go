@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const day = 24 * time.Hour
+
+// stock lasting longer than this is not given a run out date
+const runOutHorizonDays = 10 * 365
+
+// DailyUsage averages consumed over the days between since and now, counting
+// at least one day so a burst of use right after adding an item is not
+// extrapolated into a huge rate
+func DailyUsage(consumed decimal.Decimal, since time.Time, now time.Time) decimal.Decimal {
+	days := decimal.NewFromFloat(now.Sub(since).Hours() / 24)
+	if days.LessThan(decimal.NewFromInt(1)) {
+		days = decimal.NewFromInt(1)
+	}
+	return consumed.DivRound(days, 4)
+}
+
+// RunOutDate predicts when onHand runs out at dailyUsage, nil when nothing is
+// being used or it lasts longer than runOutHorizonDays
+func RunOutDate(onHand decimal.Decimal, dailyUsage decimal.Decimal, now time.Time) *time.Time {
+	if !dailyUsage.IsPositive() {
+		return nil
+	}
+
+	// checked before converting, a duration only reaches about 292 years
+	days := onHand.Div(dailyUsage)
+	if days.GreaterThan(decimal.NewFromInt(runOutHorizonDays)) {
+		return nil
+	}
+
+	runOut := now.Add(time.Duration(days.InexactFloat64() * float64(day)))
+	return &runOut
+}
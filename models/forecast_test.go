@@ -0,0 +1,46 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDailyUsage(t *testing.T) {
+	now := time.Now()
+
+	got := DailyUsage(decimal.NewFromInt(30), now.Add(-10*day), now)
+	if !got.Equal(decimal.NewFromInt(3)) {
+		t.Errorf("usage mismatch: got %v, want 3", got)
+	}
+
+	// less than a day of history counts as one day
+	got = DailyUsage(decimal.NewFromInt(5), now.Add(-time.Hour), now)
+	if !got.Equal(decimal.NewFromInt(5)) {
+		t.Errorf("usage mismatch: got %v, want 5", got)
+	}
+}
+
+func TestRunOutDate(t *testing.T) {
+	now := time.Now()
+
+	got := RunOutDate(decimal.NewFromInt(6), decimal.NewFromInt(2), now)
+	if got == nil || !got.Equal(now.Add(3*day)) {
+		t.Errorf("run out mismatch: got %v, want %v", got, now.Add(3*day))
+	}
+
+	if RunOutDate(decimal.NewFromInt(6), decimal.Zero, now) != nil {
+		t.Errorf("expected no run out date without usage")
+	}
+
+	// would overflow a duration if it were converted
+	if RunOutDate(decimal.NewFromInt(1000000), decimal.RequireFromString("0.0001"), now) != nil {
+		t.Errorf("expected no run out date beyond the horizon")
+	}
+
+	got = RunOutDate(decimal.NewFromInt(runOutHorizonDays), decimal.NewFromInt(1), now)
+	if got == nil || !got.Equal(now.Add(runOutHorizonDays*day)) {
+		t.Errorf("run out mismatch at the horizon: got %v, want %v", got, now.Add(runOutHorizonDays*day))
+	}
+}
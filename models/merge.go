@@ -0,0 +1,79 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PricedStock is what is left of one pantry entry and what was paid for it
+type PricedStock struct {
+	Quantity decimal.Decimal
+	// quantity the price was paid for, invalid when it is the same as Quantity
+	Initial decimal.NullDecimal
+	Price   decimal.NullDecimal
+	// cost of one unit once other stock was merged in, used instead of Price
+	UnitCost decimal.NullDecimal
+}
+
+// PerUnit is what one unit of the stock cost, invalid when it is unpriced
+func (s PricedStock) PerUnit() decimal.NullDecimal {
+	if s.UnitCost.Valid {
+		return s.UnitCost
+	}
+	if !s.Price.Valid {
+		return decimal.NullDecimal{}
+	}
+
+	basis := s.Quantity
+	if s.Initial.Valid && s.Initial.Decimal.IsPositive() {
+		basis = s.Initial.Decimal
+	}
+	if !basis.IsPositive() {
+		return decimal.NullDecimal{}
+	}
+
+	return decimal.NewNullDecimal(s.Price.Decimal.Div(basis))
+}
+
+// MergedUnitCost is the quantity weighted unit cost of the parts that have a
+// price, e.g. 2 units bought for 4 and 1 unit left of 2 bought for 6 cost 7/3
+// a unit. Unpriced parts take the average. Invalid when no part is priced.
+func MergedUnitCost(parts []PricedStock) decimal.NullDecimal {
+	pricedQuantity := decimal.Zero
+	cost := decimal.Zero
+
+	for _, part := range parts {
+		perUnit := part.PerUnit()
+		if !perUnit.Valid {
+			continue
+		}
+
+		pricedQuantity = pricedQuantity.Add(part.Quantity)
+		cost = cost.Add(perUnit.Decimal.Mul(part.Quantity))
+	}
+
+	if !pricedQuantity.IsPositive() {
+		return decimal.NullDecimal{}
+	}
+
+	unitCost := cost.DivRound(pricedQuantity, 4)
+	if !unitCost.IsPositive() {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NewNullDecimal(unitCost)
+}
+
+// ExpiresTogether reports whether two expiration dates are within tolerance of
+// each other, items without one only match each other
+func ExpiresTogether(a *time.Time, b *time.Time, tolerance time.Duration) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	diff := a.Sub(*b)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= tolerance
+}
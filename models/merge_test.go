@@ -0,0 +1,78 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestMergedUnitCost(t *testing.T) {
+	price := func(s string) decimal.NullDecimal {
+		return decimal.NewNullDecimal(decimal.RequireFromString(s))
+	}
+
+	tests := []struct {
+		name     string
+		parts    []PricedStock
+		expected decimal.NullDecimal
+	}{
+		{
+			"weighted by quantity",
+			[]PricedStock{
+				{Quantity: decimal.NewFromInt(2), Price: price("4")},
+				{Quantity: decimal.NewFromInt(1), Initial: price("2"), Price: price("6")},
+			},
+			price("2.3333"),
+		},
+		{
+			"unpriced parts take the average",
+			[]PricedStock{
+				{Quantity: decimal.NewFromInt(1), Price: price("3")},
+				{Quantity: decimal.NewFromInt(1)},
+			},
+			price("3"),
+		},
+		{
+			"merged unit cost wins over the price",
+			[]PricedStock{
+				{Quantity: decimal.NewFromInt(2), Initial: price("1"), Price: price("10"), UnitCost: price("1")},
+				{Quantity: decimal.NewFromInt(2), Price: price("6")},
+			},
+			price("2"),
+		},
+		{
+			"nothing priced",
+			[]PricedStock{{Quantity: decimal.NewFromInt(1)}},
+			decimal.NullDecimal{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergedUnitCost(tt.parts)
+			if got.Valid != tt.expected.Valid || !got.Decimal.Equal(tt.expected.Decimal) {
+				t.Errorf("unit cost mismatch: got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExpiresTogether(t *testing.T) {
+	now := time.Now()
+	later := now.Add(12 * time.Hour)
+	muchLater := now.Add(48 * time.Hour)
+
+	if !ExpiresTogether(&now, &later, 24*time.Hour) {
+		t.Errorf("expected dates 12h apart to match")
+	}
+	if ExpiresTogether(&now, &muchLater, 24*time.Hour) {
+		t.Errorf("expected dates 48h apart not to match")
+	}
+	if !ExpiresTogether(nil, nil, 0) {
+		t.Errorf("expected two missing dates to match")
+	}
+	if ExpiresTogether(&now, nil, 24*time.Hour) {
+		t.Errorf("expected a missing date not to match a set one")
+	}
+}
@@ -1,14 +1,22 @@
 package models
 
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
 type QuantityUnit string
 
 const (
 	// universal
 	QuantityUnset QuantityUnit = "unset"
 	QuantityCount QuantityUnit = "count"
+	QuantityDozen QuantityUnit = "dozen"
 
 	// metric
 	QuantityGrams       QuantityUnit = "grams"
+	QuantityKilograms   QuantityUnit = "kilograms"
 	QuantityMilliliters QuantityUnit = "milliliters"
 	QuantityLiters      QuantityUnit = "liters"
 
@@ -19,7 +27,137 @@ const (
 	QuantityCup         QuantityUnit = "cup"
 	QuantityPint        QuantityUnit = "pint"
 	QuantityQuart       QuantityUnit = "quart"
+	QuantityGallon      QuantityUnit = "gallon"
 
 	QuantityOunces QuantityUnit = "ounces"
 	QuantityPounds QuantityUnit = "pounds"
 )
+
+// BaseUnit is the ground truth unit a quantity is stored in, matching the
+// UNIT_TYPE enum in the database
+type BaseUnit string
+
+const (
+	BaseCountQuarter BaseUnit = "count_qtr"
+	BaseVolumeMl     BaseUnit = "volume_ml"
+	BaseMassG        BaseUnit = "mass_g"
+)
+
+type unitRatio struct {
+	base BaseUnit
+	// how many base units make up one of this unit
+	ratio decimal.Decimal
+}
+
+// see playground/units.ipynb
+var unitRatios = map[QuantityUnit]unitRatio{
+	QuantityCount: {BaseCountQuarter, decimal.NewFromInt(4)},
+	QuantityDozen: {BaseCountQuarter, decimal.NewFromInt(48)},
+
+	QuantityGrams:     {BaseMassG, decimal.NewFromInt(1)},
+	QuantityKilograms: {BaseMassG, decimal.NewFromInt(1000)},
+	QuantityOunces:    {BaseMassG, decimal.RequireFromString("28.3495")},
+	QuantityPounds:    {BaseMassG, decimal.RequireFromString("453.592")},
+
+	QuantityMilliliters: {BaseVolumeMl, decimal.NewFromInt(1)},
+	QuantityLiters:      {BaseVolumeMl, decimal.NewFromInt(1000)},
+	QuantityTeaspoon:    {BaseVolumeMl, decimal.NewFromInt(5)},
+	QuantityTablespoon:  {BaseVolumeMl, decimal.NewFromInt(15)},
+	QuantityFluidOunces: {BaseVolumeMl, decimal.NewFromInt(30)},
+	QuantityCup:         {BaseVolumeMl, decimal.NewFromInt(240)},
+	QuantityPint:        {BaseVolumeMl, decimal.NewFromInt(480)},
+	QuantityQuart:       {BaseVolumeMl, decimal.NewFromInt(950)},
+	QuantityGallon:      {BaseVolumeMl, decimal.NewFromInt(3800)},
+}
+
+// Base returns the ground truth unit this unit converts to
+func (u QuantityUnit) Base() (BaseUnit, bool) {
+	r, ok := unitRatios[u]
+	return r.base, ok
+}
+
+// ToBase converts an amount entered in any supported unit to its ground truth
+// unit, e.g. 1.5 cup -> 360 volume_ml
+func ToBase(amount decimal.Decimal, unit QuantityUnit) (decimal.Decimal, BaseUnit, error) {
+	r, ok := unitRatios[unit]
+	if !ok {
+		return decimal.Zero, "", fmt.Errorf("unsupported unit %q", unit)
+	}
+	return amount.Mul(r.ratio), r.base, nil
+}
+
+// FromBase converts a ground truth amount into the requested unit
+func FromBase(amount decimal.Decimal, base BaseUnit, unit QuantityUnit) (decimal.Decimal, error) {
+	r, ok := unitRatios[unit]
+	if !ok {
+		return decimal.Zero, fmt.Errorf("unsupported unit %q", unit)
+	}
+	if r.base != base {
+		return decimal.Zero, fmt.Errorf("cannot convert %s to %s", base, unit)
+	}
+	return amount.DivRound(r.ratio, 4), nil
+}
+
+type MeasureSystem string
+
+const (
+	MeasureMetric   MeasureSystem = "metric"
+	MeasureImperial MeasureSystem = "imperial"
+)
+
+type displayStep struct {
+	unit QuantityUnit
+	// smallest base amount this unit is used for
+	min decimal.Decimal
+}
+
+// display units per system and base, ordered from largest to smallest
+var displaySteps = map[MeasureSystem]map[BaseUnit][]displayStep{
+	MeasureMetric: {
+		BaseCountQuarter: {{QuantityCount, decimal.Zero}},
+		BaseMassG: {
+			{QuantityKilograms, decimal.NewFromInt(1000)},
+			{QuantityGrams, decimal.Zero},
+		},
+		BaseVolumeMl: {
+			{QuantityLiters, decimal.NewFromInt(1000)},
+			{QuantityMilliliters, decimal.Zero},
+		},
+	},
+	MeasureImperial: {
+		BaseCountQuarter: {{QuantityCount, decimal.Zero}},
+		BaseMassG: {
+			{QuantityPounds, decimal.RequireFromString("453.592")},
+			{QuantityOunces, decimal.Zero},
+		},
+		BaseVolumeMl: {
+			{QuantityGallon, decimal.NewFromInt(3800)},
+			{QuantityCup, decimal.NewFromInt(60)},
+			{QuantityTablespoon, decimal.NewFromInt(15)},
+			{QuantityTeaspoon, decimal.Zero},
+		},
+	},
+}
+
+// ToDisplay picks a readable unit in the given measurement system for a ground
+// truth amount, e.g. 1500 volume_ml -> 1.5 liters (metric) or 6.25 cup (imperial)
+func ToDisplay(amount decimal.Decimal, base BaseUnit, system MeasureSystem) (decimal.Decimal, QuantityUnit, error) {
+	steps, ok := displaySteps[system][base]
+	if !ok {
+		return decimal.Zero, "", fmt.Errorf("no display units for %s in %s", base, system)
+	}
+
+	unit := steps[len(steps)-1].unit
+	for _, step := range steps {
+		if amount.Abs().GreaterThanOrEqual(step.min) {
+			unit = step.unit
+			break
+		}
+	}
+
+	converted, err := FromBase(amount, base, unit)
+	if err != nil {
+		return decimal.Zero, "", err
+	}
+	return converted.Round(2), unit, nil
+}
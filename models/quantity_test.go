@@ -0,0 +1,92 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestToBase(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		unit     QuantityUnit
+		expected string
+		base     BaseUnit
+	}{
+		{"cups to ml", "1.5", QuantityCup, "360", BaseVolumeMl},
+		{"liters to ml", "2", QuantityLiters, "2000", BaseVolumeMl},
+		{"pounds to grams", "1", QuantityPounds, "453.592", BaseMassG},
+		{"count to quarters", "3", QuantityCount, "12", BaseCountQuarter},
+		{"dozen to quarters", "1", QuantityDozen, "48", BaseCountQuarter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, base, err := ToBase(decimal.RequireFromString(tt.amount), tt.unit)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if base != tt.base {
+				t.Errorf("base mismatch: got %v, want %v", base, tt.base)
+			}
+			if !got.Equal(decimal.RequireFromString(tt.expected)) {
+				t.Errorf("amount mismatch: got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestToBaseUnsupported(t *testing.T) {
+	if _, _, err := ToBase(decimal.NewFromInt(1), QuantityUnset); err == nil {
+		t.Errorf("expected an error for an unset unit")
+	}
+}
+
+func TestFromBase(t *testing.T) {
+	got, err := FromBase(decimal.NewFromInt(1500), BaseVolumeMl, QuantityLiters)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.Equal(decimal.RequireFromString("1.5")) {
+		t.Errorf("amount mismatch: got %v, want 1.5", got)
+	}
+
+	if _, err := FromBase(decimal.NewFromInt(100), BaseMassG, QuantityCup); err == nil {
+		t.Errorf("expected an error converting mass to volume")
+	}
+}
+
+func TestToDisplay(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		base     BaseUnit
+		system   MeasureSystem
+		expected string
+		unit     QuantityUnit
+	}{
+		{"metric liters", "1500", BaseVolumeMl, MeasureMetric, "1.5", QuantityLiters},
+		{"metric ml", "250", BaseVolumeMl, MeasureMetric, "250", QuantityMilliliters},
+		{"imperial cups", "1500", BaseVolumeMl, MeasureImperial, "6.25", QuantityCup},
+		{"imperial teaspoons", "10", BaseVolumeMl, MeasureImperial, "2", QuantityTeaspoon},
+		{"imperial pounds", "907.184", BaseMassG, MeasureImperial, "2", QuantityPounds},
+		{"metric kilograms", "2500", BaseMassG, MeasureMetric, "2.5", QuantityKilograms},
+		{"count", "6", BaseCountQuarter, MeasureImperial, "1.5", QuantityCount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, unit, err := ToDisplay(decimal.RequireFromString(tt.amount), tt.base, tt.system)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if unit != tt.unit {
+				t.Errorf("unit mismatch: got %v, want %v", unit, tt.unit)
+			}
+			if !got.Equal(decimal.RequireFromString(tt.expected)) {
+				t.Errorf("amount mismatch: got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package models
+
+import (
+	"encoding/csv"
+	"regexp"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// ReceiptLine is one purchased item read off a receipt. Unit is empty when the
+// receipt did not say how much was bought, Price is nil when no price was found.
+type ReceiptLine struct {
+	Raw      string           `json:"raw"`
+	Name     string           `json:"name"`
+	Quantity decimal.Decimal  `json:"quantity"`
+	Unit     QuantityUnit     `json:"unit"`
+	Price    *decimal.Decimal `json:"price"`
+}
+
+var receiptUnits = map[string]QuantityUnit{
+	"kg":    QuantityKilograms,
+	"g":     QuantityGrams,
+	"lb":    QuantityPounds,
+	"lbs":   QuantityPounds,
+	"oz":    QuantityOunces,
+	"l":     QuantityLiters,
+	"ml":    QuantityMilliliters,
+	"gal":   QuantityGallon,
+	"doz":   QuantityDozen,
+	"dozen": QuantityDozen,
+	"ct":    QuantityCount,
+	"pk":    QuantityCount,
+}
+
+var (
+	// totals, payment and other lines that are not items
+	receiptSkipRe = regexp.MustCompile(`(?i)\b(sub\s*total|total|tax|change|cash|visa|mastercard|amex|debit|credit|balance|tender|savings|discount|coupon)\b`)
+	// trailing price, optionally followed by a tax flag such as "F" or "T"
+	receiptPriceRe = regexp.MustCompile(`\$?(\d+[.,]\d{2})\s*[A-Za-z]?$`)
+	// per unit prices such as "@ 0.59/lb" only repeat the line price
+	receiptUnitPriceRe  = regexp.MustCompile(`@\s*\$?\d+(?:[.,]\d+)?(?:\s*/\s*\w+)?`)
+	receiptMultiplierRe = regexp.MustCompile(`^(\d+)\s*(?:x|\*|@)?\s+`)
+	receiptMeasureRe    = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(kg|g|lbs|lb|oz|ml|l|gal|dozen|doz|ct|pk)\b`)
+	receiptDozenRe      = regexp.MustCompile(`(?i)\b(dozen|doz)\b`)
+)
+
+func parseReceiptDecimal(s string) (decimal.Decimal, error) {
+	return decimal.NewFromString(strings.Replace(s, ",", ".", 1))
+}
+
+// ParseReceiptLine reads one line of plain receipt text, returning false for
+// lines that are not items
+func ParseReceiptLine(raw string) (ReceiptLine, bool) {
+	line := strings.TrimSpace(raw)
+	if line == "" || receiptSkipRe.MatchString(line) {
+		return ReceiptLine{}, false
+	}
+
+	item := ReceiptLine{Raw: raw, Quantity: decimal.NewFromInt(1)}
+
+	if m := receiptPriceRe.FindStringSubmatchIndex(line); m != nil {
+		if price, err := parseReceiptDecimal(line[m[2]:m[3]]); err == nil {
+			item.Price = &price
+		}
+		line = line[:m[0]]
+	}
+
+	line = receiptUnitPriceRe.ReplaceAllString(line, " ")
+
+	multiplier := decimal.NewFromInt(1)
+	if m := receiptMultiplierRe.FindStringSubmatch(line); m != nil {
+		multiplier, _ = decimal.NewFromString(m[1])
+		line = line[len(m[0]):]
+	}
+
+	if m := receiptMeasureRe.FindStringSubmatch(line); m != nil {
+		if amount, err := parseReceiptDecimal(m[1]); err == nil {
+			item.Quantity = amount
+			item.Unit = receiptUnits[strings.ToLower(m[2])]
+		}
+		line = strings.Replace(line, m[0], " ", 1)
+	} else if m := receiptDozenRe.FindString(line); m != "" {
+		item.Unit = QuantityDozen
+		line = strings.Replace(line, m, " ", 1)
+	} else if !multiplier.Equal(decimal.NewFromInt(1)) {
+		item.Unit = QuantityCount
+	}
+
+	item.Quantity = item.Quantity.Mul(multiplier)
+	item.Name = strings.Join(strings.Fields(strings.Trim(line, " -*#:")), " ")
+
+	if item.Name == "" {
+		return ReceiptLine{}, false
+	}
+
+	return item, true
+}
+
+// csv exports need a header naming at least the item column
+var receiptColumns = map[string][]string{
+	"name":     {"name", "item", "description", "product"},
+	"quantity": {"quantity", "qty", "amount"},
+	"unit":     {"unit", "uom"},
+	"price":    {"price", "total", "cost"},
+}
+
+func receiptColumnIndexes(header []string) (map[string]int, bool) {
+	indexes := map[string]int{}
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		for key, names := range receiptColumns {
+			for _, name := range names {
+				if _, found := indexes[key]; !found && column == name {
+					indexes[key] = i
+				}
+			}
+		}
+	}
+
+	_, ok := indexes["name"]
+	return indexes, ok
+}
+
+func parseReceiptCSV(records [][]string, indexes map[string]int) []ReceiptLine {
+	field := func(record []string, key string) string {
+		i, ok := indexes[key]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	lines := []ReceiptLine{}
+	for _, record := range records {
+		name := field(record, "name")
+		if name == "" || receiptSkipRe.MatchString(name) {
+			continue
+		}
+
+		item := ReceiptLine{
+			Raw:      strings.Join(record, ","),
+			Name:     name,
+			Quantity: decimal.NewFromInt(1),
+		}
+
+		if quantity, err := parseReceiptDecimal(field(record, "quantity")); err == nil {
+			item.Quantity = quantity
+		}
+
+		if unit := strings.ToLower(field(record, "unit")); unit != "" {
+			if known, ok := receiptUnits[unit]; ok {
+				item.Unit = known
+			} else if _, ok := QuantityUnit(unit).Base(); ok {
+				item.Unit = QuantityUnit(unit)
+			}
+		}
+
+		if price, err := parseReceiptDecimal(strings.TrimPrefix(field(record, "price"), "$")); err == nil {
+			item.Price = &price
+		}
+
+		lines = append(lines, item)
+	}
+
+	return lines
+}
+
+// ParseReceipt reads pasted receipt text, either a csv export with a header
+// row or one item per line
+func ParseReceipt(text string) []ReceiptLine {
+	records, err := csv.NewReader(strings.NewReader(text)).ReadAll()
+	if err == nil && len(records) > 1 {
+		if indexes, ok := receiptColumnIndexes(records[0]); ok {
+			return parseReceiptCSV(records[1:], indexes)
+		}
+	}
+
+	lines := []ReceiptLine{}
+	for _, raw := range strings.Split(text, "\n") {
+		if item, ok := ParseReceiptLine(raw); ok {
+			lines = append(lines, item)
+		}
+	}
+
+	return lines
+}
+
+func bigrams(s string) map[string]int {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if ('a' <= r && r <= 'z') || ('0' <= r && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+
+	grams := map[string]int{}
+	for _, word := range strings.Fields(b.String()) {
+		padded := " " + word + " "
+		for i := 0; i < len(padded)-1; i++ {
+			grams[padded[i:i+2]]++
+		}
+	}
+
+	return grams
+}
+
+// Similarity scores how alike two item names are from 0 to 1, using the dice
+// coefficient over character bigrams so abbreviations still score well
+func Similarity(a string, b string) float64 {
+	gramsA, gramsB := bigrams(a), bigrams(b)
+
+	total, shared := 0, 0
+	for gram, count := range gramsA {
+		total += count
+		shared += min(count, gramsB[gram])
+	}
+	for _, count := range gramsB {
+		total += count
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return float64(2*shared) / float64(total)
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestParseReceiptLine(t *testing.T) {
+	tests := []struct {
+		raw      string
+		name     string
+		quantity string
+		unit     QuantityUnit
+		price    string
+	}{
+		{"WHOLE MILK 2L        3.49", "WHOLE MILK", "2", QuantityLiters, "3.49"},
+		{"2 x GREEK YOGURT 500g  $7.98 F", "GREEK YOGURT", "1000", QuantityGrams, "7.98"},
+		{"BANANAS 1.2 lb @ 0.59/lb   0.71", "BANANAS", "1.2", QuantityPounds, "0.71"},
+		{"LARGE EGGS DOZEN 4,99", "LARGE EGGS", "1", QuantityDozen, "4.99"},
+		{"3 AVOCADO 4.50", "AVOCADO", "3", QuantityCount, "4.50"},
+		{"SOURDOUGH LOAF 5.25", "SOURDOUGH LOAF", "1", "", "5.25"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, ok := ParseReceiptLine(tt.raw)
+			if !ok {
+				t.Fatalf("line was skipped")
+			}
+			if got.Name != tt.name {
+				t.Errorf("name mismatch: got %q, want %q", got.Name, tt.name)
+			}
+			if !got.Quantity.Equal(decimal.RequireFromString(tt.quantity)) || got.Unit != tt.unit {
+				t.Errorf("quantity mismatch: got %v %v, want %v %v", got.Quantity, got.Unit, tt.quantity, tt.unit)
+			}
+			if got.Price == nil || !got.Price.Equal(decimal.RequireFromString(tt.price)) {
+				t.Errorf("price mismatch: got %v, want %v", got.Price, tt.price)
+			}
+		})
+	}
+}
+
+func TestParseReceiptSkipsTotals(t *testing.T) {
+	for _, raw := range []string{"SUBTOTAL 12.40", "TOTAL 13.02", "VISA 13.02", "   "} {
+		if _, ok := ParseReceiptLine(raw); ok {
+			t.Errorf("expected %q to be skipped", raw)
+		}
+	}
+}
+
+func TestParseReceiptCSV(t *testing.T) {
+	lines := ParseReceipt("Item,Qty,Unit,Price\nOat Milk,2,l,5.98\nTotal,,,5.98\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected 1 line, got %d", len(lines))
+	}
+	if lines[0].Name != "Oat Milk" || lines[0].Unit != QuantityLiters || !lines[0].Quantity.Equal(decimal.NewFromInt(2)) {
+		t.Errorf("unexpected line: %+v", lines[0])
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	if s := Similarity("BANANAS", "banana"); s < 0.7 {
+		t.Errorf("expected banana to match, got %v", s)
+	}
+	if Similarity("WHOLE MILK", "milk") <= Similarity("WHOLE MILK", "flour") {
+		t.Errorf("expected milk to score above flour")
+	}
+}
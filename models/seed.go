@@ -0,0 +1,138 @@
+package models
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// SeedIngredient is one row of an ingredient catalog file. CSV files use the
+// snake_case column names, JSON files the camelCase keys.
+type SeedIngredient struct {
+	Name           string   `json:"name"`
+	Unit           string   `json:"unit"`
+	StorageLoc     string   `json:"storageLoc"`
+	IngredientType string   `json:"ingredientType"`
+	Aliases        []string `json:"aliases"`
+	ShelfLifeDays  *int32   `json:"shelfLifeDays"`
+	// days the ingredient keeps once opened
+	OpenedShelfLifeDays *int32 `json:"openedShelfLifeDays"`
+}
+
+// SeedRow tracks where a row came from so errors can point back at the file.
+// Line is 1-based: the file line for CSV, the array position for JSON.
+type SeedRow struct {
+	Line       int
+	Ingredient SeedIngredient
+	Err        error
+}
+
+// a row that does not decode only fails itself, like a bad CSV line
+func ParseSeedJSON(r io.Reader) ([]SeedRow, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	rows := make([]SeedRow, len(raw))
+	for i, message := range raw {
+		rows[i] = SeedRow{Line: i + 1}
+		if err := json.Unmarshal(message, &rows[i].Ingredient); err != nil {
+			rows[i] = SeedRow{Line: i + 1, Err: fmt.Errorf("invalid ingredient: %w", err)}
+		}
+	}
+	return rows, nil
+}
+
+// aliases are separated with "|" since commas are taken by the CSV itself
+func ParseSeedCSV(r io.Reader) ([]SeedRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("could not read csv header: %w", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	for _, required := range []string{"name", "unit", "storage_loc", "ingredient_type"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("csv is missing required column %q", required)
+		}
+	}
+
+	get := func(record []string, column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var rows []SeedRow
+	// line 1 is the header
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		row := SeedIngredient{
+			Name:           get(record, "name"),
+			Unit:           get(record, "unit"),
+			StorageLoc:     get(record, "storage_loc"),
+			IngredientType: get(record, "ingredient_type"),
+		}
+
+		if aliases := get(record, "aliases"); aliases != "" {
+			row.Aliases = strings.Split(aliases, "|")
+		}
+
+		var rowErr error
+		row.ShelfLifeDays, rowErr = parseSeedDays(get(record, "shelf_life_days"), "shelf_life_days")
+		if rowErr == nil {
+			row.OpenedShelfLifeDays, rowErr = parseSeedDays(get(record, "opened_shelf_life_days"), "opened_shelf_life_days")
+		}
+
+		rows = append(rows, SeedRow{Line: line, Ingredient: row, Err: rowErr})
+	}
+
+	return rows, nil
+}
+
+// parseSeedDays reads an optional day count from a CSV cell
+func parseSeedDays(value string, column string) (*int32, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	days, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", column, value)
+	}
+
+	parsed := int32(days)
+	return &parsed, nil
+}
+
+// ParseSeedFile picks the parser from a file extension or content type
+func ParseSeedFile(format string, r io.Reader) ([]SeedRow, error) {
+	switch format {
+	case "csv":
+		return ParseSeedCSV(r)
+	case "json":
+		return ParseSeedJSON(r)
+	}
+	return nil, fmt.Errorf("unsupported seed format %q, expected csv or json", format)
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func int32Ptr(v int32) *int32 {
+	return &v
+}
+
+func TestParseSeedCSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		rows  []SeedRow
+		// the row at this index should carry an error, -1 for none
+		errRow int
+	}{
+		{
+			"basic rows",
+			"name,unit,storage_loc,ingredient_type\nMilk,volume_ml,fridge,dairy_eggs\nRice,mass_g,pantry,essentials\n",
+			[]SeedRow{
+				{Line: 2, Ingredient: SeedIngredient{Name: "Milk", Unit: "volume_ml", StorageLoc: "fridge", IngredientType: "dairy_eggs"}},
+				{Line: 3, Ingredient: SeedIngredient{Name: "Rice", Unit: "mass_g", StorageLoc: "pantry", IngredientType: "essentials"}},
+			},
+			-1,
+		},
+		{
+			"optional columns in any order",
+			"Ingredient_Type, name, unit, storage_loc, aliases, shelf_life_days, opened_shelf_life_days\nproduce, Scallion, count_qtr, fridge, green onion|spring onion, 10, 5\n",
+			[]SeedRow{
+				{Line: 2, Ingredient: SeedIngredient{
+					Name:                "Scallion",
+					Unit:                "count_qtr",
+					StorageLoc:          "fridge",
+					IngredientType:      "produce",
+					Aliases:             []string{"green onion", "spring onion"},
+					ShelfLifeDays:       int32Ptr(10),
+					OpenedShelfLifeDays: int32Ptr(5),
+				}},
+			},
+			-1,
+		},
+		{
+			"bad day count only fails its row",
+			"name,unit,storage_loc,ingredient_type,shelf_life_days\nMilk,volume_ml,fridge,dairy_eggs,a week\nRice,mass_g,pantry,essentials,365\n",
+			[]SeedRow{
+				{Line: 2, Ingredient: SeedIngredient{Name: "Milk", Unit: "volume_ml", StorageLoc: "fridge", IngredientType: "dairy_eggs"}},
+				{Line: 3, Ingredient: SeedIngredient{Name: "Rice", Unit: "mass_g", StorageLoc: "pantry", IngredientType: "essentials", ShelfLifeDays: int32Ptr(365)}},
+			},
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ParseSeedCSV(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(rows) != len(tt.rows) {
+				t.Fatalf("row count mismatch: got %d, want %d", len(rows), len(tt.rows))
+			}
+			for i, row := range rows {
+				if (row.Err != nil) != (i == tt.errRow) {
+					t.Errorf("row %d error mismatch: got %v", i, row.Err)
+				}
+				row.Err = nil
+				if !reflect.DeepEqual(row, tt.rows[i]) {
+					t.Errorf("row %d mismatch: got %+v, want %+v", i, row, tt.rows[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSeedCSVMissingColumn(t *testing.T) {
+	if _, err := ParseSeedCSV(strings.NewReader("name,unit,storage_loc\nMilk,volume_ml,fridge\n")); err == nil {
+		t.Errorf("expected an error for a missing ingredient_type column")
+	}
+}
+
+func TestParseSeedJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		rows    []SeedRow
+		invalid bool
+	}{
+		{
+			"rows are numbered from one",
+			`[{"name": "Milk", "unit": "volume_ml", "storageLoc": "fridge", "ingredientType": "dairy_eggs", "shelfLifeDays": 7},
+			  {"name": "Rice", "unit": "mass_g", "storageLoc": "pantry", "ingredientType": "essentials", "aliases": ["white rice"]}]`,
+			[]SeedRow{
+				{Line: 1, Ingredient: SeedIngredient{Name: "Milk", Unit: "volume_ml", StorageLoc: "fridge", IngredientType: "dairy_eggs", ShelfLifeDays: int32Ptr(7)}},
+				{Line: 2, Ingredient: SeedIngredient{Name: "Rice", Unit: "mass_g", StorageLoc: "pantry", IngredientType: "essentials", Aliases: []string{"white rice"}}},
+			},
+			false,
+		},
+		{"empty catalog", `[]`, []SeedRow{}, false},
+		{
+			"bad row only fails itself",
+			`[{"name": "Milk", "shelfLifeDays": "a week"}, {"name": "Rice"}]`,
+			[]SeedRow{
+				{Line: 1},
+				{Line: 2, Ingredient: SeedIngredient{Name: "Rice"}},
+			},
+			false,
+		},
+		{"not an array", `{"name": "Milk"}`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := ParseSeedJSON(strings.NewReader(tt.input))
+			if (err != nil) != tt.invalid {
+				t.Fatalf("error mismatch: got %v", err)
+			}
+			if tt.invalid {
+				return
+			}
+			if len(rows) != len(tt.rows) {
+				t.Fatalf("row count mismatch: got %d, want %d", len(rows), len(tt.rows))
+			}
+			for i, row := range rows {
+				// rows that decoded to nothing are the ones that should fail
+				failed := reflect.DeepEqual(tt.rows[i].Ingredient, SeedIngredient{})
+				if (row.Err != nil) != failed {
+					t.Errorf("row %d error mismatch: got %v", i, row.Err)
+				}
+				row.Err = nil
+				if !reflect.DeepEqual(row, tt.rows[i]) {
+					t.Errorf("row %d mismatch: got %+v, want %+v", i, row, tt.rows[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSeedFileUnknownFormat(t *testing.T) {
+	if _, err := ParseSeedFile("xml", strings.NewReader("")); err == nil {
+		t.Errorf("expected an error for an unsupported format")
+	}
+}
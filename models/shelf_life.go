@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// fewer finished entries than this are too thin to learn a shelf life from
+const MinShelfLifeSamples = 3
+
+type ShelfLifeSource string
+
+const (
+	ShelfLifeFromHistory ShelfLifeSource = "history"
+	ShelfLifeFromCatalog ShelfLifeSource = "catalog"
+)
+
+// EstimateShelfLife prefers how long the user's own entries lasted, given as
+// the median over samples finished entries, and falls back to the catalog's
+// shelf life. Learned estimates last at least a day. ok is false when neither
+// is known.
+func EstimateShelfLife(samples int32, medianDays decimal.Decimal, catalogDays *int32) (shelfLife time.Duration, source ShelfLifeSource, ok bool) {
+	if samples >= MinShelfLifeSamples {
+		shelfLife = time.Duration(medianDays.InexactFloat64() * float64(day))
+		return max(shelfLife, day), ShelfLifeFromHistory, true
+	}
+
+	if catalogDays != nil {
+		return time.Duration(*catalogDays) * day, ShelfLifeFromCatalog, true
+	}
+
+	return 0, "", false
+}
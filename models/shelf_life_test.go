@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestEstimateShelfLife(t *testing.T) {
+	catalog := int32(7)
+
+	tests := []struct {
+		name        string
+		samples     int32
+		medianDays  decimal.Decimal
+		catalogDays *int32
+		shelfLife   time.Duration
+		source      ShelfLifeSource
+		ok          bool
+	}{
+		{"learned", 5, decimal.NewFromFloat(3.5), &catalog, 84 * time.Hour, ShelfLifeFromHistory, true},
+		{"learned without catalog", 3, decimal.NewFromInt(10), nil, 10 * day, ShelfLifeFromHistory, true},
+		{"learned at least a day", 4, decimal.NewFromFloat(0.2), &catalog, day, ShelfLifeFromHistory, true},
+		{"thin history", 2, decimal.NewFromInt(30), &catalog, 7 * day, ShelfLifeFromCatalog, true},
+		{"nothing known", 0, decimal.Zero, nil, 0, "", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			shelfLife, source, ok := EstimateShelfLife(test.samples, test.medianDays, test.catalogDays)
+			if shelfLife != test.shelfLife || source != test.source || ok != test.ok {
+				t.Errorf("got (%v, %q, %v), want (%v, %q, %v)", shelfLife, source, ok, test.shelfLife, test.source, test.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// how much longer food keeps in each kind of storage, relative to the pantry
+var shelfLifeFactors = map[Category]int64{
+	CategoryPantry:  1,
+	CategoryFridge:  2,
+	CategoryFreezer: 12,
+}
+
+// thawed food spoils about as fast as fresh food, so leaving the freezer caps
+// the remaining shelf life
+const ThawedShelfLife = 2 * 24 * time.Hour
+
+// AdjustExpiry scales the shelf life left on an item when it moves between
+// kinds of storage, e.g. moving something with 3 days left from the pantry to
+// the freezer gives it 36. Items that already expired keep their expiration.
+func AdjustExpiry(expiration time.Time, now time.Time, from Category, to Category) (time.Time, error) {
+	fromFactor, ok := shelfLifeFactors[from]
+	if !ok {
+		return expiration, fmt.Errorf("unknown storage kind %q", from)
+	}
+
+	toFactor, ok := shelfLifeFactors[to]
+	if !ok {
+		return expiration, fmt.Errorf("unknown storage kind %q", to)
+	}
+
+	remaining := expiration.Sub(now)
+	if from == to || remaining <= 0 {
+		return expiration, nil
+	}
+
+	remaining = remaining * time.Duration(toFactor) / time.Duration(fromFactor)
+	if from == CategoryFreezer && remaining > ThawedShelfLife {
+		remaining = ThawedShelfLife
+	}
+
+	return now.Add(remaining), nil
+}
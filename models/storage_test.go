@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdjustExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	tests := []struct {
+		name      string
+		remaining time.Duration
+		from      Category
+		to        Category
+		expected  time.Duration
+	}{
+		{"freezing extends", 3 * day, CategoryFridge, CategoryFreezer, 18 * day},
+		{"fridge from pantry", 5 * day, CategoryPantry, CategoryFridge, 10 * day},
+		{"pantry from fridge", 4 * day, CategoryFridge, CategoryPantry, 2 * day},
+		{"thawing caps", 60 * day, CategoryFreezer, CategoryFridge, ThawedShelfLife},
+		{"same kind", 7 * day, CategoryFridge, CategoryFridge, 7 * day},
+		{"already expired", -day, CategoryFridge, CategoryFreezer, -day},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AdjustExpiry(now.Add(tt.remaining), now, tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(now.Add(tt.expected)) {
+				t.Errorf("expiry mismatch: got %v, want %v", got, now.Add(tt.expected))
+			}
+		})
+	}
+}
+
+func TestAdjustExpiryUnknownKind(t *testing.T) {
+	if _, err := AdjustExpiry(time.Now(), time.Now(), CategoryUnset, CategoryFridge); err == nil {
+		t.Errorf("expected an error for an unset storage kind")
+	}
+}
@@ -1,16 +1,127 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
 	"pantree/api/db"
+	"pantree/api/models"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/shopspring/decimal"
 )
 
+type PantryItemResponse struct {
+	db.Userpantryview
+	DisplayQuantity decimal.Decimal     `json:"displayQuantity"`
+	DisplayUnit     models.QuantityUnit `json:"displayUnit"`
+	// predicted from the ingredient's total across locations, null when it
+	// has not been used lately
+	DailyUsage *decimal.Decimal `json:"dailyUsage"`
+	RunOutDate *time.Time       `json:"runOutDate"`
+}
+
+// adds quantities converted to the user's preferred measurement system next
+// to the ground truth ones, and when each ingredient is expected to run out
+func toPantryItemResponses(pantry []db.Userpantryview, forecasts map[uuid.UUID]ingredientForecast) []PantryItemResponse {
+	response := make([]PantryItemResponse, len(pantry))
+	for i, item := range pantry {
+		response[i] = PantryItemResponse{Userpantryview: item}
+
+		if item.IngredientID != nil {
+			if forecast, ok := forecasts[*item.IngredientID]; ok && forecast.RunOutDate != nil {
+				response[i].DailyUsage = &forecast.DailyUsage
+				response[i].RunOutDate = forecast.RunOutDate
+			}
+		}
+
+		quantity, unit, err := models.ToDisplay(item.Quantity, models.BaseUnit(item.Unit), models.MeasureSystem(item.UserMeasurementSystem))
+
+		if err != nil {
+			// fall back to the ground truth values so the item still shows up
+			log.Println("Could not convert pantry quantity for display:", err)
+			quantity, unit = item.Quantity, models.QuantityUnit(item.Unit)
+		}
+
+		response[i].DisplayQuantity = quantity
+		response[i].DisplayUnit = unit
+	}
+
+	return response
+}
+
+var pantrySorts = map[string]bool{"expiry": true, "name": true, "quantity": true}
+
+// reads the optional getPantry filters from the query string, responding with
+// 400 and returning false when one is invalid
+func _parsePantryFilter(c *gin.Context, userUuid uuid.UUID) (db.FilterUserPantryParams, bool) {
+	filter := db.FilterUserPantryParams{
+		UserID: userUuid,
+		Sort:   c.DefaultQuery("sort", "name"),
+	}
+
+	if !pantrySorts[filter.Sort] {
+		sendError(c, 400, fmt.Errorf("unknown sort %q", filter.Sort), "Sort must be one of expiry, name or quantity.")
+		return filter, false
+	}
+
+	if loc := db.LocType(c.Query("storageLoc")); loc != "" {
+		if !isValidLocType(loc) {
+			sendError(c, 400, fmt.Errorf("invalid storage location %q", loc), "Invalid storage location.")
+			return filter, false
+		}
+		filter.StorageLoc = db.NullLocType{LocType: loc, Valid: true}
+	}
+
+	if grocType := db.GrocType(c.Query("ingredientType")); grocType != "" {
+		if !isValidGrocType(grocType) {
+			sendError(c, 400, fmt.Errorf("invalid ingredient type %q", grocType), "Invalid ingredient type.")
+			return filter, false
+		}
+		filter.IngredientType = db.NullGrocType{GrocType: grocType, Valid: true}
+	}
+
+	if name := c.Query("name"); name != "" {
+		filter.Name = getPgtypeText(name)
+	}
+
+	if tags := normalizeTags([]string{c.Query("tag")}); len(tags) > 0 {
+		filter.Tag = getPgtypeText(tags[0])
+	}
+
+	now := time.Now()
+	expired := c.Query("expired") == "true"
+
+	if within := c.Query("expiringWithin"); within != "" {
+		days, err := strconv.Atoi(within)
+
+		if err != nil || days < 0 {
+			sendError(c, 400, fmt.Errorf("invalid expiringWithin %q", within), "expiringWithin must be a number of days.")
+			return filter, false
+		}
+
+		before := now.AddDate(0, 0, days)
+		filter.ExpiresBefore = &before
+
+		// already expired items only show up when asked for
+		if !expired {
+			filter.ExpiresAfter = &now
+		}
+	} else if expired {
+		filter.ExpiresBefore = &now
+	}
+
+	return filter, true
+}
+
 /**
- * /getIngredients
+ * /getPantry
  */
 func _handleGetPantry(c *gin.Context) {
 	userUuid, err := getUserId(c)
@@ -19,33 +130,205 @@ func _handleGetPantry(c *gin.Context) {
 		log.Println("Unable to get user UUID: \n", err)
 	}
 
+	filter, ok := _parsePantryFilter(c, userUuid)
+	if !ok {
+		return
+	}
+
 	log.Printf("Getting pantry for user %s\n", userUuid)
-	pantry, err := queries.GetUserPantry(c, db.GetUserPantryParams{
-		UserID: &userUuid,
-	})
+	pantry, err := queries.FilterUserPantry(c, filter)
 
 	if err != nil {
 		log.Println("Could not get pantry:", err)
 		c.JSON(500, gin.H{
 			"message": "Could not get pantry.",
 		})
+		return
 	}
 
-	if pantry == nil {
-		pantry = []db.GetUserPantryRow{}
+	forecasts, err := getPantryForecasts(c, userUuid, filter, pantry)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not forecast pantry usage.")
+		return
 	}
 
-	c.JSON(200, pantry)
+	c.JSON(200, toPantryItemResponses(pantry, forecasts))
+}
+
+/**
+ * /expiring
+ */
+const defaultExpiringDays = 3
+
+// items that expire within the given number of days (default 3), including
+// ones that already have, soonest first
+func _handleGetExpiring(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	days := defaultExpiringDays
+	if param := c.Query("days"); param != "" {
+		days, err = strconv.Atoi(param)
+
+		if err != nil || days < 0 {
+			sendError(c, 400, fmt.Errorf("invalid days %q", param), "days must be a number of days.")
+			return
+		}
+	}
+
+	before := time.Now().AddDate(0, 0, days)
+	filter := db.FilterUserPantryParams{
+		UserID:        userUuid,
+		ExpiresBefore: &before,
+		Sort:          "expiry",
+	}
+	pantry, err := queries.FilterUserPantry(c, filter)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get expiring items.")
+		return
+	}
+
+	forecasts, err := getPantryForecasts(c, userUuid, filter, pantry)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not forecast pantry usage.")
+		return
+	}
+
+	c.JSON(200, toPantryItemResponses(pantry, forecasts))
 }
 
 /**
  * /createItem
  */
 type CreateUserItemRequest struct {
-	IngredientId   uuid.UUID `json:"ingredientId" binding:"required"`
-	Quantity       int64     `json:"quantity" binding:"required"`
-	Price          *float64  `json:"price"`
-	ExpirationDate *float64  `json:"expirationDate"`
+	IngredientId uuid.UUID       `json:"ingredientId" binding:"required"`
+	Quantity     decimal.Decimal `json:"quantity" binding:"required"`
+	// unit the quantity is given in, empty for the ingredient's ground truth unit
+	Unit           models.QuantityUnit `json:"unit"`
+	Price          *float64            `json:"price"`
+	ExpirationDate *float64            `json:"expirationDate"`
+	// overrides the ingredient's storage_loc for this entry
+	LocationId   *uuid.UUID `json:"locationId"`
+	Brand        *string    `json:"brand"`
+	Store        *string    `json:"store"`
+	PurchaseDate *float64   `json:"purchaseDate"`
+	Note         *string    `json:"note"`
+	Tags         []string   `json:"tags"`
+}
+
+// pantryError carries the status and message a failed pantry change responds
+// with, so the single endpoints and /batch can share the change itself
+type pantryError struct {
+	status  int
+	message string
+	err     error
+}
+
+func (e *pantryError) Error() string {
+	return e.err.Error()
+}
+
+func newPantryError(status int, err error, message string) *pantryError {
+	return &pantryError{status: status, message: message, err: err}
+}
+
+func sendPantryError(c *gin.Context, perr *pantryError) {
+	sendError(c, perr.status, perr.err, perr.message)
+}
+
+// checks on a create request that do not need the database
+func validateCreateUserItem(request *CreateUserItemRequest) *pantryError {
+	if !request.Quantity.IsPositive() {
+		return newPantryError(400, fmt.Errorf("quantity %s is not positive", request.Quantity), "Quantity must be positive.")
+	}
+
+	if _, ok := request.Unit.Base(); request.Unit != "" && !ok {
+		return newPantryError(400, fmt.Errorf("unsupported unit %q", request.Unit), "Invalid unit.")
+	}
+
+	if request.Price != nil && *request.Price <= 0 {
+		return newPantryError(400, fmt.Errorf("price %v is not positive", *request.Price), "Price must be positive.")
+	}
+
+	return nil
+}
+
+// createUserItem creates the entry a validated request describes, or merges it
+// into a matching one
+func createUserItem(ctx context.Context, qtx *db.Queries, source entryChangeSource, userUuid uuid.UUID, request *CreateUserItemRequest) (db.Useritementry, *pantryError) {
+	if request.LocationId != nil {
+		_, err := qtx.GetStorageLocation(ctx, db.GetStorageLocationParams{
+			ID:     *request.LocationId,
+			UserID: userUuid,
+		})
+
+		if err == pgx.ErrNoRows {
+			return db.Useritementry{}, newPantryError(404, err, "Storage location not found.")
+		}
+
+		if err != nil {
+			return db.Useritementry{}, newPantryError(500, err, "Could not get storage location.")
+		}
+	}
+
+	ingredient, err := qtx.GetVisibleIngredient(ctx, db.GetVisibleIngredientParams{
+		ID:     request.IngredientId,
+		UserID: userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		return db.Useritementry{}, newPantryError(404, err, "Ingredient not found.")
+	}
+
+	if err != nil {
+		return db.Useritementry{}, newPantryError(500, err, "Could not get ingredient.")
+	}
+
+	quantity, err := toIngredientQuantity(request.Quantity, request.Unit, ingredient.Unit)
+
+	if err != nil {
+		return db.Useritementry{}, newPantryError(400, err, "Invalid unit.")
+	}
+
+	var enteredUnit pgtype.Text
+	if request.Unit != "" {
+		enteredUnit = getPgtypeText(string(request.Unit))
+	}
+
+	var price decimal.NullDecimal
+	if request.Price != nil {
+		price = decimal.NewNullDecimal(decimal.NewFromFloat(*request.Price))
+	}
+
+	params := db.CreateUserItemEntryParams{
+		UserID:         &userUuid,
+		IngredientID:   &ingredient.ID,
+		Quantity:       quantity,
+		Price:          price,
+		ExpirationDate: msToTime(request.ExpirationDate),
+		LocationID:     request.LocationId,
+		EnteredUnit:    enteredUnit,
+		Brand:          optionalText(request.Brand),
+		Store:          optionalText(request.Store),
+		PurchaseDate:   msToTime(request.PurchaseDate),
+		Note:           optionalText(request.Note),
+		Tags:           normalizeTags(request.Tags),
+	}
+
+	item, err := createOrMergeUserItem(ctx, qtx, source, params)
+
+	if err != nil {
+		return item, newPantryError(500, err, "Could not create user item.")
+	}
+
+	return item, nil
 }
 
 func _handleAddUserItem(c *gin.Context) {
@@ -63,37 +346,756 @@ func _handleAddUserItem(c *gin.Context) {
 		return
 	}
 
+	if perr := validateCreateUserItem(&request); perr != nil {
+		sendPantryError(c, perr)
+		return
+	}
+
 	log.Printf("Creating new user item for user %s\n", userUuid)
 
-	// create quantity
-	quantity := decimal.NewFromInt(request.Quantity)
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+
+	item, perr := createUserItem(ctx, qtx, changeSourceFromContext(c, userUuid), userUuid, &request)
+
+	if perr != nil {
+		log.Println("Could not create user item: ", perr)
+		sendPantryError(c, perr)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	log.Printf("Successfully created user item: %v\n", item.ID)
+	c.JSON(200, item)
+}
+
+/**
+ * /getEntries
+ */
+func _handleGetUserItems(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	items, err := queries.ListUserItemEntries(c, db.ListUserItemEntriesParams{
+		UserID:         &userUuid,
+		IncludeDeleted: c.Query("includeDeleted") == "true",
+	})
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get user items.")
+		return
+	}
+
+	if items == nil {
+		items = []db.Useritementry{}
+	}
+
+	c.JSON(200, items)
+}
+
+// getOwnUserItem loads one of the user's live entries, missing, deleted and
+// other users' entries are all not found
+func getOwnUserItem(ctx context.Context, qtx *db.Queries, userUuid uuid.UUID, id uuid.UUID) (db.Useritementry, *pantryError) {
+	item, err := qtx.GetUserItemEntry(ctx, db.GetUserItemEntryParams{
+		ID:     id,
+		UserID: &userUuid,
+	})
+
+	if err == pgx.ErrNoRows || (err == nil && item.Deleted) {
+		return item, newPantryError(404, fmt.Errorf("user item %s not found", id), "User item not found.")
+	}
+
+	if err != nil {
+		return item, newPantryError(500, err, "Could not get user item.")
+	}
+
+	return item, nil
+}
+
+// loads one of the caller's entries, responding 404 for missing, deleted or
+// other users' entries
+func _getOwnUserItem(c *gin.Context, userUuid uuid.UUID, id uuid.UUID) (db.Useritementry, bool) {
+	item, perr := getOwnUserItem(c, queries, userUuid, id)
+
+	if perr != nil {
+		sendPantryError(c, perr)
+		return item, false
+	}
+
+	return item, true
+}
+
+/**
+ * /getEntry
+ */
+func _handleGetUserItem(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	id, err := uuid.Parse(c.Query("id"))
+
+	if err != nil {
+		sendError(c, 400, err, "Invalid user item id.")
+		return
+	}
+
+	item, ok := _getOwnUserItem(c, userUuid, id)
+	if !ok {
+		return
+	}
+
+	c.JSON(200, item)
+}
+
+/**
+ * /updateItem
+ */
+type UpdateUserItemRequest struct {
+	ID       uuid.UUID        `json:"id" binding:"required"`
+	Quantity *decimal.Decimal `json:"quantity"`
+	// unit the quantity is given in, empty for the ingredient's ground truth unit
+	Unit           models.QuantityUnit `json:"unit"`
+	Price          *float64            `json:"price"`
+	ExpirationDate *float64            `json:"expirationDate"`
+	// what happened to the quantity taken off the entry, consumed when left
+	// out
+	Outcome db.DisposalOutcome `json:"outcome"`
+}
+
+// checks on an update request that do not need the database
+func validateUpdateUserItem(request *UpdateUserItemRequest) *pantryError {
+	if request.Quantity != nil && request.Quantity.IsNegative() {
+		return newPantryError(400, fmt.Errorf("quantity %s is negative", request.Quantity), "Quantity cannot be negative.")
+	}
+
+	if _, ok := request.Unit.Base(); request.Unit != "" && !ok {
+		return newPantryError(400, fmt.Errorf("unsupported unit %q", request.Unit), "Invalid unit.")
+	}
+
+	if request.Price != nil && *request.Price <= 0 {
+		return newPantryError(400, fmt.Errorf("price %v is not positive", *request.Price), "Price must be positive.")
+	}
+
+	if request.Outcome != "" && !isValidDisposalOutcome(request.Outcome) {
+		return newPantryError(400, fmt.Errorf("invalid outcome %q", request.Outcome), "Invalid disposal outcome.")
+	}
+
+	return nil
+}
+
+// updateUserItem applies a validated request to one of the user's entries,
+// fields left out of the request keep their current values. Any drop in
+// quantity is recorded as a disposal and an entry that reaches zero is
+// soft-deleted.
+func updateUserItem(ctx context.Context, qtx *db.Queries, source entryChangeSource, userUuid uuid.UUID, request *UpdateUserItemRequest) (db.Useritementry, *pantryError) {
+	item, perr := getOwnUserItem(ctx, qtx, userUuid, request.ID)
+	if perr != nil {
+		return item, perr
+	}
+
+	params := db.UpdateUserItemEntryParams{
+		Quantity:       item.Quantity,
+		Price:          item.Price,
+		ExpirationDate: item.ExpirationDate,
+		ID:             item.ID,
+		UserID:         &userUuid,
+	}
+
+	if request.Quantity != nil {
+		params.Quantity = *request.Quantity
+	}
+
+	if request.Quantity != nil && request.Unit != "" {
+		// leftovers are counted in portions and have no unit to convert from
+		if item.IngredientID == nil {
+			return item, newPantryError(400, fmt.Errorf("user item %s has no ingredient", item.ID), "Only ingredient entries take a unit.")
+		}
+
+		// the entry already points at the ingredient, so it stays usable even if
+		// the user can no longer see it
+		ingredient, err := qtx.GetIngredient(ctx, *item.IngredientID)
+
+		if err != nil {
+			return item, newPantryError(500, err, "Could not get ingredient.")
+		}
+
+		params.Quantity, err = toIngredientQuantity(*request.Quantity, request.Unit, ingredient.Unit)
+
+		if err != nil {
+			return item, newPantryError(400, err, "Invalid unit.")
+		}
+	}
 
-	var price decimal.NullDecimal
 	if request.Price != nil {
-		price.Decimal = decimal.NewFromFloat(*request.Price)
-		price.Valid = true
+		params.Price = decimal.NewNullDecimal(decimal.NewFromFloat(*request.Price))
+	}
+
+	if request.ExpirationDate != nil {
+		params.ExpirationDate = msToTime(request.ExpirationDate)
+	}
+
+	updated, err := qtx.UpdateUserItemEntry(ctx, params)
+
+	if err == pgx.ErrNoRows {
+		return updated, newPantryError(404, err, "User item not found.")
+	}
+
+	if err != nil {
+		return updated, newPantryError(500, err, "Could not update user item.")
+	}
+
+	if updated.Quantity.IsZero() {
+		updated, err = qtx.DeleteUserItemEntry(ctx, db.DeleteUserItemEntryParams{
+			ID:     item.ID,
+			UserID: &userUuid,
+		})
+
+		if err != nil {
+			return updated, newPantryError(500, err, "Could not delete user item.")
+		}
+	}
+
+	// lowering the quantity is usage like any other, so it counts toward
+	// consumption forecasts and waste
+	outcome := request.Outcome
+	if outcome == "" {
+		outcome = db.DisposalOutcomeConsumed
+	}
+
+	if err := recordDisposal(ctx, qtx, item, outcome, item.Quantity.Sub(params.Quantity)); err != nil {
+		return updated, newPantryError(500, err, "Could not record disposal.")
+	}
+
+	if err := recordEntryChange(ctx, qtx, source, entryActionFor(&item, &updated), &item, &updated); err != nil {
+		return updated, newPantryError(500, err, "Could not record user item history.")
+	}
+
+	return updated, nil
+}
+
+func _handleUpdateUserItem(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request UpdateUserItemRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	if perr := validateUpdateUserItem(&request); perr != nil {
+		sendPantryError(c, perr)
+		return
+	}
+
+	log.Printf("Updating user item %s for user %s\n", request.ID, userUuid)
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+
+	updated, perr := updateUserItem(ctx, qtx, changeSourceFromContext(c, userUuid), userUuid, &request)
+
+	if perr != nil {
+		sendPantryError(c, perr)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	c.JSON(200, updated)
+}
+
+/**
+ * /open
+ */
+type OpenUserItemRequest struct {
+	ID uuid.UUID `json:"id" binding:"required"`
+	// ms since epoch, defaults to now
+	OpenedAt *float64 `json:"openedAt"`
+}
+
+func _handleOpenUserItem(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request OpenUserItemRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	item, ok := _getOwnUserItem(c, userUuid, request.ID)
+	if !ok {
+		return
+	}
+
+	if item.OpenedAt != nil {
+		sendError(c, 409, fmt.Errorf("user item %s was opened at %s", item.ID, item.OpenedAt), "User item is already open.")
+		return
+	}
+
+	openedAt := time.Now()
+	if request.OpenedAt != nil {
+		openedAt = *msToTime(request.OpenedAt)
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+
+	opened, err := qtx.OpenUserItemEntry(ctx, db.OpenUserItemEntryParams{
+		OpenedAt: &openedAt,
+		ID:       item.ID,
+		UserID:   &userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, 404, err, "User item not found.")
+		return
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not open user item.")
+		return
+	}
+
+	err = recordEntryChange(ctx, qtx, changeSourceFromContext(c, userUuid), db.EntryActionUpdate, &item, &opened)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not record user item history.")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	c.JSON(200, opened)
+}
+
+/**
+ * /deleteItem
+ */
+type DeleteUserItemRequest struct {
+	ID uuid.UUID `json:"id" binding:"required"`
+	// what happened to whatever was left of the entry
+	Outcome db.DisposalOutcome `json:"outcome" binding:"required"`
+}
+
+// checks on a delete request that do not need the database
+func validateDeleteUserItem(request *DeleteUserItemRequest) *pantryError {
+	if !isValidDisposalOutcome(request.Outcome) {
+		return newPantryError(400, fmt.Errorf("invalid outcome %q", request.Outcome), "Invalid disposal outcome.")
+	}
+
+	return nil
+}
+
+// deleteUserItem soft-deletes one of the user's entries and records what
+// happened to whatever was left of it
+func deleteUserItem(ctx context.Context, qtx *db.Queries, source entryChangeSource, userUuid uuid.UUID, request *DeleteUserItemRequest) (db.Useritementry, *pantryError) {
+	item, perr := getOwnUserItem(ctx, qtx, userUuid, request.ID)
+	if perr != nil {
+		return item, perr
+	}
+
+	deleted, err := qtx.DeleteUserItemEntry(ctx, db.DeleteUserItemEntryParams{
+		ID:     item.ID,
+		UserID: &userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		return deleted, newPantryError(404, err, "User item not found.")
+	}
+
+	if err != nil {
+		return deleted, newPantryError(500, err, "Could not delete user item.")
+	}
+
+	if err := recordEntryChange(ctx, qtx, source, db.EntryActionDelete, &item, &deleted); err != nil {
+		return deleted, newPantryError(500, err, "Could not record user item history.")
+	}
+
+	if err := recordDisposal(ctx, qtx, item, request.Outcome, item.Quantity); err != nil {
+		return deleted, newPantryError(500, err, "Could not record disposal.")
+	}
+
+	return deleted, nil
+}
+
+func _handleDeleteUserItem(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request DeleteUserItemRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	if perr := validateDeleteUserItem(&request); perr != nil {
+		sendPantryError(c, perr)
+		return
+	}
+
+	log.Printf("Deleting user item %s for user %s\n", request.ID, userUuid)
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	deleted, perr := deleteUserItem(ctx, queries.WithTx(tx), changeSourceFromContext(c, userUuid), userUuid, &request)
+
+	if perr != nil {
+		sendPantryError(c, perr)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	c.JSON(200, deleted)
+}
+
+// converts an amount entered in any supported unit into the ingredient's
+// ground truth unit; an empty unit means the amount already is in it
+func toIngredientQuantity(amount decimal.Decimal, unit models.QuantityUnit, ingredientUnit db.UnitType) (decimal.Decimal, error) {
+	if unit == "" {
+		return amount, nil
+	}
+
+	converted, base, err := models.ToBase(amount, unit)
+	if err != nil {
+		return decimal.Zero, err
+	}
+
+	if db.UnitType(base) != ingredientUnit {
+		return decimal.Zero, fmt.Errorf("unit %s cannot be used for an ingredient measured in %s", unit, ingredientUnit)
+	}
+
+	return converted, nil
+}
+
+type InsufficientStockError struct {
+	Requested decimal.Decimal
+	Available decimal.Decimal
+}
+
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("requested %s but only %s is available", e.Requested, e.Available)
+}
+
+// recordDisposal notes what happened to quantity taken out of entry, which is
+// the entry as it was before the change
+func recordDisposal(ctx context.Context, qtx *db.Queries, entry db.Useritementry, outcome db.DisposalOutcome, quantity decimal.Decimal) error {
+	if !quantity.IsPositive() {
+		return nil
+	}
+
+	var cost decimal.NullDecimal
+	if perUnit := entryStock(entry).PerUnit(); perUnit.Valid {
+		cost = decimal.NewNullDecimal(perUnit.Decimal.Mul(quantity).Round(2))
 	}
 
-	item, err := queries.CreateUserItemEntry(c, db.CreateUserItemEntryParams{
+	return qtx.CreateUserItemDisposal(ctx, db.CreateUserItemDisposalParams{
+		EntryID:  entry.ID,
+		UserID:   entry.UserID,
+		Outcome:  outcome,
+		Quantity: quantity,
+		Cost:     cost,
+	})
+}
+
+// setUserItemQuantity changes an entry's quantity, soft-deleting it once it
+// reaches zero, and records the change in its history
+func setUserItemQuantity(ctx context.Context, qtx *db.Queries, source entryChangeSource, entry db.Useritementry, quantity decimal.Decimal) (db.Useritementry, error) {
+	updated, err := qtx.UpdateUserItemEntry(ctx, db.UpdateUserItemEntryParams{
+		Quantity:       quantity,
+		Price:          entry.Price,
+		ExpirationDate: entry.ExpirationDate,
+		ID:             entry.ID,
+		UserID:         entry.UserID,
+	})
+
+	if err != nil {
+		return updated, err
+	}
+
+	if updated.Quantity.IsZero() {
+		updated, err = qtx.DeleteUserItemEntry(ctx, db.DeleteUserItemEntryParams{
+			ID:     entry.ID,
+			UserID: entry.UserID,
+		})
+
+		if err != nil {
+			return updated, err
+		}
+	}
+
+	return updated, recordEntryChange(ctx, qtx, source, entryActionFor(&entry, &updated), &entry, &updated)
+}
+
+// consumeUserItems takes amount (in the ingredient's ground truth unit) out of
+// the user's entries, first-expiring first, soft-deleting entries that run out.
+// Callers run it inside a transaction so a shortfall leaves nothing changed.
+func consumeUserItems(ctx context.Context, qtx *db.Queries, source entryChangeSource, userUuid uuid.UUID, ingredientId uuid.UUID, amount decimal.Decimal, outcome db.DisposalOutcome) ([]db.Useritementry, error) {
+	entries, err := qtx.GetConsumableUserItemEntries(ctx, db.GetConsumableUserItemEntriesParams{
 		UserID:       &userUuid,
-		IngredientID: &request.IngredientId,
-		Quantity:     quantity,
-		Price:        price,
+		IngredientID: &ingredientId,
 	})
 
 	if err != nil {
-		log.Println("Could not create user item: ", err)
-		sendError(c, 500, err, "Could not create user item.")
+		return nil, err
+	}
+
+	available := decimal.Zero
+	for _, entry := range entries {
+		available = available.Add(entry.Quantity)
+	}
+
+	if available.LessThan(amount) {
+		return nil, &InsufficientStockError{Requested: amount, Available: available}
+	}
+
+	remaining := amount
+	changed := []db.Useritementry{}
+	for _, entry := range entries {
+		if !remaining.IsPositive() {
+			break
+		}
+
+		taken := decimal.Min(remaining, entry.Quantity)
+		remaining = remaining.Sub(taken)
+
+		updated, err := setUserItemQuantity(ctx, qtx, source, entry, entry.Quantity.Sub(taken))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := recordDisposal(ctx, qtx, entry, outcome, taken); err != nil {
+			return nil, err
+		}
+
+		changed = append(changed, updated)
+	}
+
+	return changed, nil
+}
+
+/**
+ * /consume
+ */
+type ConsumeRequest struct {
+	IngredientID uuid.UUID           `json:"ingredientId" binding:"required"`
+	Quantity     decimal.Decimal     `json:"quantity" binding:"required"`
+	Unit         models.QuantityUnit `json:"unit"`
+	Outcome      db.DisposalOutcome  `json:"outcome" binding:"required"`
+}
+
+// checks on a consume request that do not need the database
+func validateConsume(request *ConsumeRequest) *pantryError {
+	if !request.Quantity.IsPositive() {
+		return newPantryError(400, fmt.Errorf("quantity %s is not positive", request.Quantity), "Quantity must be positive.")
+	}
+
+	if _, ok := request.Unit.Base(); request.Unit != "" && !ok {
+		return newPantryError(400, fmt.Errorf("unsupported unit %q", request.Unit), "Invalid unit.")
+	}
+
+	if !isValidDisposalOutcome(request.Outcome) {
+		return newPantryError(400, fmt.Errorf("invalid outcome %q", request.Outcome), "Invalid disposal outcome.")
+	}
+
+	return nil
+}
+
+// consumeUserItem takes a validated request's quantity of an ingredient out of
+// the user's entries, first-expiring first
+func consumeUserItem(ctx context.Context, qtx *db.Queries, source entryChangeSource, userUuid uuid.UUID, request *ConsumeRequest) ([]db.Useritementry, *pantryError) {
+	ingredient, err := qtx.GetVisibleIngredient(ctx, db.GetVisibleIngredientParams{
+		ID:     request.IngredientID,
+		UserID: userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		return nil, newPantryError(404, err, "Ingredient not found.")
+	}
+
+	if err != nil {
+		return nil, newPantryError(500, err, "Could not get ingredient.")
+	}
+
+	amount, err := toIngredientQuantity(request.Quantity, request.Unit, ingredient.Unit)
+
+	if err != nil {
+		return nil, newPantryError(400, err, "Invalid unit.")
+	}
+
+	log.Printf("Consuming %s %s of %s for user %s\n", amount, ingredient.Unit, ingredient.ID, userUuid)
+
+	changed, err := consumeUserItems(ctx, qtx, source, userUuid, ingredient.ID, amount, request.Outcome)
+
+	var shortfall *InsufficientStockError
+	if errors.As(err, &shortfall) {
+		return nil, newPantryError(409, err, "Not enough in the pantry.")
+	}
+
+	if err != nil {
+		return nil, newPantryError(500, err, "Could not consume user items.")
+	}
+
+	return changed, nil
+}
+
+func _handleConsume(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
 		return
 	}
 
-	log.Printf("Successfully created user item: %v\n", item.ID)
-	c.JSON(200, item)
+	var request ConsumeRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	if perr := validateConsume(&request); perr != nil {
+		sendPantryError(c, perr)
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	changed, perr := consumeUserItem(ctx, queries.WithTx(tx), changeSourceFromContext(c, userUuid), userUuid, &request)
+
+	if perr != nil {
+		sendPantryError(c, perr)
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	c.JSON(200, changed)
 }
 
 func registerPantryRoutes(router *gin.RouterGroup) {
 	router.GET("/getPantry", _handleGetPantry)
+	router.GET("/expiring", _handleGetExpiring)
+	router.GET("/forecast", _handleGetForecast)
+	router.GET("/suggestExpiration", _handleSuggestExpiration)
 	router.POST("/createItem", _handleAddUserItem)
-	// router.GET("/getEntries", _handleGetIngredients)
-	// router.POST("/newIngredient", _handleNewIngredient)
+	router.GET("/getEntries", _handleGetUserItems)
+	router.GET("/getEntry", _handleGetUserItem)
+	router.POST("/updateItem", _handleUpdateUserItem)
+	router.POST("/open", _handleOpenUserItem)
+	router.POST("/deleteItem", _handleDeleteUserItem)
+	router.POST("/consume", _handleConsume)
+	router.POST("/batch", _handleBatch)
+	router.POST("/compact", _handleCompactPantry)
+	router.POST("/addLeftovers", _handleAddLeftovers)
+	router.GET("/entryHistory", _handleGetEntryHistory)
+	router.GET("/history", _handleGetUserHistory)
+	router.POST("/restoreEntry", _handleRestoreEntry)
+	router.GET("/locations", _handleGetStorageLocations)
+	router.POST("/newLocation", _handleNewStorageLocation)
+	router.POST("/deleteLocation", _handleDeleteStorageLocation)
+	router.POST("/move", _handleMoveUserItems)
+	router.POST("/parseReceipt", _handleParseReceipt)
+	router.POST("/confirmReceipt", _handleConfirmReceipt)
+	router.GET("/parLevels", getParLevels)
+	router.POST("/setParLevel", setParLevel)
+	router.POST("/removeParLevel", removeParLevel)
+	router.GET("/lowStock", getLowStock)
+	router.POST("/restock", restockShoppingList)
+	router.GET("/export", _handleExportPantry)
+	router.POST("/import", _handleImportPantry)
+	router.GET("/stocktakes", _handleGetStocktakes)
+	router.POST("/startStocktake", _handleStartStocktake)
+	router.POST("/stocktakeCount", _handleStocktakeCount)
+	router.GET("/stocktakeDiff", _handleGetStocktakeDiff)
+	router.POST("/applyStocktake", _handleApplyStocktake)
 }
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"pantree/api/db"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/google/uuid"
+)
+
+const maxBatchOperations = 200
+
+/**
+ * /batch
+ */
+type BatchOperation struct {
+	// one of create, update, delete or consume
+	Op string `json:"op" binding:"required"`
+	// the body the matching single endpoint takes
+	Data json.RawMessage `json:"data" binding:"required"`
+}
+
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations" binding:"required,dive"`
+}
+
+type BatchResult struct {
+	Index   int                `json:"index"`
+	Op      string             `json:"op"`
+	Entries []db.Useritementry `json:"entries"`
+	// why the operation failed, empty when it did not
+	Error string `json:"error,omitempty"`
+}
+
+// a decoded operation, exactly one of the requests is set
+type batchStep struct {
+	create  *CreateUserItemRequest
+	update  *UpdateUserItemRequest
+	delete  *DeleteUserItemRequest
+	consume *ConsumeRequest
+}
+
+// decodes an operation and runs the checks that do not need the database
+func parseBatchOperation(operation BatchOperation) (batchStep, *pantryError) {
+	var step batchStep
+	var request any
+
+	switch operation.Op {
+	case "create":
+		step.create = &CreateUserItemRequest{}
+		request = step.create
+	case "update":
+		step.update = &UpdateUserItemRequest{}
+		request = step.update
+	case "delete":
+		step.delete = &DeleteUserItemRequest{}
+		request = step.delete
+	case "consume":
+		step.consume = &ConsumeRequest{}
+		request = step.consume
+	default:
+		return step, newPantryError(400, fmt.Errorf("unknown op %q", operation.Op), "Op must be one of create, update, delete or consume.")
+	}
+
+	if err := json.Unmarshal(operation.Data, request); err != nil {
+		return step, newPantryError(400, err, "Invalid operation data.")
+	}
+
+	if err := binding.Validator.ValidateStruct(request); err != nil {
+		return step, newPantryError(400, err, "Invalid operation data.")
+	}
+
+	switch {
+	case step.create != nil:
+		return step, validateCreateUserItem(step.create)
+	case step.update != nil:
+		return step, validateUpdateUserItem(step.update)
+	case step.delete != nil:
+		return step, validateDeleteUserItem(step.delete)
+	case step.consume != nil:
+		return step, validateConsume(step.consume)
+	}
+
+	return step, nil
+}
+
+func applyBatchCreate(ctx context.Context, qtx *db.Queries, source entryChangeSource, userUuid uuid.UUID, request *CreateUserItemRequest) ([]db.Useritementry, *pantryError) {
+	item, perr := createUserItem(ctx, qtx, source, userUuid, request)
+	if perr != nil {
+		return nil, perr
+	}
+
+	return []db.Useritementry{item}, nil
+}
+
+func applyBatchUpdate(ctx context.Context, qtx *db.Queries, source entryChangeSource, userUuid uuid.UUID, request *UpdateUserItemRequest) ([]db.Useritementry, *pantryError) {
+	updated, perr := updateUserItem(ctx, qtx, source, userUuid, request)
+	if perr != nil {
+		return nil, perr
+	}
+
+	return []db.Useritementry{updated}, nil
+}
+
+func applyBatchDelete(ctx context.Context, qtx *db.Queries, source entryChangeSource, userUuid uuid.UUID, request *DeleteUserItemRequest) ([]db.Useritementry, *pantryError) {
+	deleted, perr := deleteUserItem(ctx, qtx, source, userUuid, request)
+	if perr != nil {
+		return nil, perr
+	}
+
+	return []db.Useritementry{deleted}, nil
+}
+
+// every operation is checked before anything is written, then all of them are
+// applied in one transaction so a failure part way through changes nothing
+func _handleBatch(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request BatchRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	if len(request.Operations) > maxBatchOperations {
+		sendError(c, 400, fmt.Errorf("%d operations", len(request.Operations)), fmt.Sprintf("A batch can have at most %d operations.", maxBatchOperations))
+		return
+	}
+
+	steps := make([]batchStep, len(request.Operations))
+	results := make([]BatchResult, len(request.Operations))
+	invalid := 0
+	for i, operation := range request.Operations {
+		results[i] = BatchResult{Index: i, Op: operation.Op, Entries: []db.Useritementry{}}
+
+		step, perr := parseBatchOperation(operation)
+		if perr != nil {
+			results[i].Error = perr.message
+			invalid++
+			continue
+		}
+
+		steps[i] = step
+	}
+
+	if invalid > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"applied": false,
+			"results": results,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+	source := changeSourceFromContext(c, userUuid)
+
+	log.Printf("Applying %d batch operations for user %s\n", len(steps), userUuid)
+
+	for i, step := range steps {
+		var entries []db.Useritementry
+		var perr *pantryError
+
+		switch {
+		case step.create != nil:
+			entries, perr = applyBatchCreate(ctx, qtx, source, userUuid, step.create)
+		case step.update != nil:
+			entries, perr = applyBatchUpdate(ctx, qtx, source, userUuid, step.update)
+		case step.delete != nil:
+			entries, perr = applyBatchDelete(ctx, qtx, source, userUuid, step.delete)
+		case step.consume != nil:
+			entries, perr = consumeUserItem(ctx, qtx, source, userUuid, step.consume)
+		}
+
+		if perr != nil {
+			log.Printf("Batch operation %d failed for user %s: %v\n", i, userUuid, perr)
+
+			// nothing was committed, so no operation has entries to report
+			for j := range results {
+				results[j].Entries = []db.Useritementry{}
+			}
+			results[i].Error = perr.message
+
+			c.JSON(perr.status, gin.H{
+				"applied": false,
+				"results": results,
+			})
+			return
+		}
+
+		results[i].Entries = entries
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"applied": true,
+		"results": results,
+	})
+}
@@ -0,0 +1,514 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"pantree/api/db"
+	"pantree/api/models"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+)
+
+// PantryRecord is one pantry entry as it appears in an export or import file.
+// Price is what the quantity in the record cost, not the original purchase.
+type PantryRecord struct {
+	Ingredient     string              `json:"ingredient"`
+	Quantity       decimal.Decimal     `json:"quantity"`
+	Unit           models.QuantityUnit `json:"unit"`
+	Location       string              `json:"location"`
+	Price          *decimal.Decimal    `json:"price"`
+	ExpirationDate *time.Time          `json:"expirationDate"`
+}
+
+// pantryFileRow is a record read from an import file, or why it could not be.
+// Line is 1-based: the file line for CSV, the array position for JSON.
+type pantryFileRow struct {
+	Line   int
+	Record PantryRecord
+	Err    error
+}
+
+var pantryCSVHeader = []string{"ingredient", "quantity", "unit", "location", "price", "expiration_date"}
+
+// dates in csv files may be a full timestamp or just the day
+var pantryDateLayouts = []string{time.RFC3339, "2006-01-02"}
+
+// shows an entry in the unit it was entered in, otherwise in the user's
+// measurement system
+func exportQuantity(row db.GetPantryExportRow, system models.MeasureSystem) (decimal.Decimal, models.QuantityUnit) {
+	base := models.BaseUnit(row.IngredientUnit)
+
+	if row.EnteredUnit.Valid {
+		quantity, err := models.FromBase(row.Quantity, base, models.QuantityUnit(row.EnteredUnit.String))
+		if err == nil {
+			return quantity, models.QuantityUnit(row.EnteredUnit.String)
+		}
+	}
+
+	quantity, unit, err := models.ToDisplay(row.Quantity, base, system)
+	if err != nil {
+		return row.Quantity, models.QuantityUnit(row.IngredientUnit)
+	}
+
+	return quantity, unit
+}
+
+func writePantryCSV(w io.Writer, records []PantryRecord) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(pantryCSVHeader); err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		price, expiration := "", ""
+		if record.Price != nil {
+			price = record.Price.StringFixed(2)
+		}
+		if record.ExpirationDate != nil {
+			expiration = record.ExpirationDate.Format(time.RFC3339)
+		}
+
+		err := writer.Write([]string{
+			record.Ingredient,
+			record.Quantity.String(),
+			string(record.Unit),
+			record.Location,
+			price,
+			expiration,
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// a line with a bad value only fails itself, like the seed catalog
+func parsePantryCSV(r io.Reader) ([]pantryFileRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	if _, ok := columns["ingredient"]; !ok {
+		return nil, fmt.Errorf("missing ingredient column")
+	}
+
+	field := func(row []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	rows := []pantryFileRow{}
+	// line 1 is the header
+	for line := 2; ; line++ {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := parsePantryCSVRecord(row, field)
+		rows = append(rows, pantryFileRow{Line: line, Record: record, Err: err})
+	}
+
+	return rows, nil
+}
+
+func parsePantryCSVRecord(row []string, field func([]string, string) string) (PantryRecord, error) {
+	record := PantryRecord{
+		Ingredient: field(row, "ingredient"),
+		Unit:       models.QuantityUnit(field(row, "unit")),
+		Location:   field(row, "location"),
+	}
+
+	var err error
+	record.Quantity, err = decimal.NewFromString(field(row, "quantity"))
+	if err != nil {
+		return record, fmt.Errorf("invalid quantity: %w", err)
+	}
+
+	if price := field(row, "price"); price != "" {
+		parsed, err := decimal.NewFromString(price)
+		if err != nil {
+			return record, fmt.Errorf("invalid price: %w", err)
+		}
+		record.Price = &parsed
+	}
+
+	if expiration := field(row, "expiration_date"); expiration != "" {
+		for _, layout := range pantryDateLayouts {
+			if parsed, err := time.Parse(layout, expiration); err == nil {
+				record.ExpirationDate = &parsed
+				break
+			}
+		}
+		if record.ExpirationDate == nil {
+			return record, fmt.Errorf("invalid expiration date %q", expiration)
+		}
+	}
+
+	return record, nil
+}
+
+func parsePantryJSON(r io.Reader) ([]pantryFileRow, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	rows := make([]pantryFileRow, len(raw))
+	for i, message := range raw {
+		rows[i] = pantryFileRow{Line: i + 1}
+		if err := json.Unmarshal(message, &rows[i].Record); err != nil {
+			rows[i] = pantryFileRow{Line: i + 1, Err: fmt.Errorf("invalid record: %w", err)}
+		}
+	}
+	return rows, nil
+}
+
+func parsePantryFile(format string, r io.Reader) ([]pantryFileRow, error) {
+	switch format {
+	case "csv":
+		return parsePantryCSV(r)
+	case "json":
+		return parsePantryJSON(r)
+	}
+	return nil, fmt.Errorf("unsupported pantry format %q, expected csv or json", format)
+}
+
+/**
+ * /export
+ */
+func _handleExportPantry(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		sendError(c, 400, fmt.Errorf("unsupported format %q", format), "Format must be csv or json.")
+		return
+	}
+
+	user, err := queries.GetUser(c, db.GetUserParams{ID: &userUuid})
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get user.")
+		return
+	}
+
+	rows, err := queries.GetPantryExport(c, &userUuid)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get pantry.")
+		return
+	}
+
+	records := make([]PantryRecord, len(rows))
+	for i, row := range rows {
+		quantity, unit := exportQuantity(row, models.MeasureSystem(user.PrefMeasure))
+		records[i] = PantryRecord{
+			Ingredient:     row.IngredientName,
+			Quantity:       quantity,
+			Unit:           unit,
+			Location:       row.Location,
+			ExpirationDate: row.ExpirationDate,
+		}
+
+		// priced for what is left, so a re-import keeps the unit cost
+		stock := models.PricedStock{
+			Quantity: row.Quantity,
+			Initial:  row.InitialQuantity,
+			Price:    row.Price,
+			UnitCost: row.UnitCost,
+		}
+		if perUnit := stock.PerUnit(); perUnit.Valid {
+			price := perUnit.Decimal.Mul(row.Quantity).Round(2)
+			records[i].Price = &price
+		}
+	}
+
+	log.Printf("Exporting %d user items for user %s as %s\n", len(records), userUuid, format)
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=pantry.%s", format))
+
+	if format == "json" {
+		c.JSON(200, records)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Status(200)
+	if err := writePantryCSV(c.Writer, records); err != nil {
+		log.Println("Could not write pantry csv:", err)
+	}
+}
+
+/**
+ * /import
+ */
+type ImportPreviewRow struct {
+	PantryRecord
+	Line           int              `json:"line"`
+	IngredientID   *uuid.UUID       `json:"ingredientId"`
+	IngredientName string           `json:"ingredientName"`
+	LocationID     *uuid.UUID       `json:"locationId"`
+	BaseQuantity   *decimal.Decimal `json:"baseQuantity"`
+	// set for base storage kinds other than the ingredient's own
+	StorageLoc *db.LocType `json:"storageLoc"`
+	// why the row cannot be imported, empty when it can
+	Error string `json:"error"`
+}
+
+// matchImportIngredient finds the ingredient a record names, exact is false
+// when the name only resembles it
+func matchImportIngredient(name string, ingredients []db.Ingredient) (match db.Ingredient, exact bool, ok bool) {
+	name = strings.ToLower(normalizeIngredientName(name))
+
+	for _, ingredient := range ingredients {
+		if strings.ToLower(ingredient.Name) == name {
+			return ingredient, true, true
+		}
+		for _, alias := range ingredient.Aliases {
+			if strings.ToLower(alias) == name {
+				return ingredient, true, true
+			}
+		}
+	}
+
+	var best db.Ingredient
+	bestScore := 0.0
+	for _, ingredient := range ingredients {
+		if score := ingredientSimilarity(name, ingredient); score > bestScore {
+			best, bestScore = ingredient, score
+		}
+	}
+
+	return best, false, bestScore >= receiptMatchThreshold
+}
+
+func previewImportRecord(file pantryFileRow, ingredients []db.Ingredient, locations []db.Storagelocation) ImportPreviewRow {
+	record := file.Record
+	row := ImportPreviewRow{PantryRecord: record, Line: file.Line}
+
+	if file.Err != nil {
+		row.Error = file.Err.Error()
+		return row
+	}
+
+	ingredient, exact, ok := matchImportIngredient(record.Ingredient, ingredients)
+	if !ok {
+		row.Error = "no matching ingredient"
+		return row
+	}
+
+	row.IngredientID = &ingredient.ID
+	row.IngredientName = ingredient.Name
+
+	// a close name is only a suggestion, the file has to use the exact one
+	// before it is imported
+	if !exact {
+		row.Error = fmt.Sprintf("no exact match, did you mean %q", ingredient.Name)
+		return row
+	}
+
+	quantity, err := toIngredientQuantity(record.Quantity, record.Unit, ingredient.Unit)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+
+	if !quantity.IsPositive() {
+		row.Error = "quantity must be positive"
+		return row
+	}
+
+	row.BaseQuantity = &quantity
+
+	// a base storage kind is kept on the entry when it is not where the
+	// ingredient usually lives, anything else has to be one of the user's own
+	// locations
+	if base := db.LocType(strings.ToLower(record.Location)); isValidLocType(base) {
+		if base != ingredient.StorageLoc {
+			row.StorageLoc = &base
+		}
+	} else if record.Location != "" {
+		for _, location := range locations {
+			if strings.EqualFold(location.Name, record.Location) {
+				row.LocationID = &location.ID
+			}
+		}
+
+		if row.LocationID == nil {
+			row.Error = fmt.Sprintf("unknown location %q", record.Location)
+		}
+	}
+
+	return row
+}
+
+// previews how a csv or json file would be imported, and unless preview=true
+// creates every entry in one transaction once all rows match
+func _handleImportPantry(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var body io.Reader
+	format := c.Query("format")
+
+	if file, header, err := c.Request.FormFile("file"); err == nil {
+		defer file.Close()
+		body = file
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(path.Ext(header.Filename)), ".")
+		}
+	} else {
+		body = c.Request.Body
+		if format == "" {
+			format = "json"
+			if strings.Contains(c.ContentType(), "csv") {
+				format = "csv"
+			}
+		}
+	}
+
+	fileRows, err := parsePantryFile(format, body)
+
+	if err != nil {
+		sendError(c, 400, err, "Could not parse pantry file.")
+		return
+	}
+
+	ingredients, err := queries.GetIngredients(c, userUuid)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get ingredients.")
+		return
+	}
+
+	locations, err := queries.GetStorageLocations(c, userUuid)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get storage locations.")
+		return
+	}
+
+	rows := make([]ImportPreviewRow, len(fileRows))
+	unmatched := 0
+	for i, fileRow := range fileRows {
+		rows[i] = previewImportRecord(fileRow, ingredients, locations)
+		if rows[i].Error != "" {
+			unmatched++
+		}
+	}
+
+	if c.Query("preview") == "true" || unmatched > 0 {
+		status := http.StatusOK
+		if c.Query("preview") != "true" {
+			status = http.StatusUnprocessableEntity
+		}
+
+		c.JSON(status, gin.H{
+			"imported":  0,
+			"unmatched": unmatched,
+			"rows":      rows,
+		})
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+	source := changeSourceFromContext(c, userUuid)
+
+	created := make([]db.Useritementry, 0, len(rows))
+	for _, row := range rows {
+		var price decimal.NullDecimal
+		if row.Price != nil && row.Price.IsPositive() {
+			price = decimal.NewNullDecimal(*row.Price)
+		}
+
+		var enteredUnit pgtype.Text
+		if row.Unit != "" {
+			enteredUnit = getPgtypeText(string(row.Unit))
+		}
+
+		var storageLoc db.NullLocType
+		if row.StorageLoc != nil {
+			storageLoc = db.NullLocType{LocType: *row.StorageLoc, Valid: true}
+		}
+
+		entry, err := createOrMergeUserItem(ctx, qtx, source, db.CreateUserItemEntryParams{
+			UserID:         &userUuid,
+			IngredientID:   row.IngredientID,
+			Quantity:       *row.BaseQuantity,
+			Price:          price,
+			ExpirationDate: row.ExpirationDate,
+			LocationID:     row.LocationID,
+			EnteredUnit:    enteredUnit,
+			StorageLoc:     storageLoc,
+		})
+
+		if err != nil {
+			sendError(c, 500, err, "Could not create user item.")
+			return
+		}
+
+		created = append(created, entry)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	log.Printf("Imported %d user items for user %s\n", len(created), userUuid)
+	c.JSON(200, gin.H{
+		"imported":  len(created),
+		"unmatched": 0,
+		"entries":   created,
+	})
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"pantree/api/db"
+	"pantree/api/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+)
+
+/**
+ * /parLevels
+ */
+func getParLevels(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	levels, err := queries.GetParLevels(c, userUuid)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get par levels")
+		return
+	}
+
+	if levels == nil {
+		levels = []db.GetParLevelsRow{}
+	}
+
+	c.JSON(http.StatusOK, levels)
+}
+
+/**
+ * /setParLevel
+ */
+type SetParLevelRequest struct {
+	IngredientID uuid.UUID           `json:"ingredientId" binding:"required"`
+	Quantity     decimal.Decimal     `json:"quantity" binding:"required"`
+	Unit         models.QuantityUnit `json:"unit"`
+}
+
+func setParLevel(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	var request SetParLevelRequest
+	if err := c.BindJSON(&request); err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid request body")
+		return
+	}
+
+	if !request.Quantity.IsPositive() {
+		sendError(c, http.StatusBadRequest, fmt.Errorf("quantity %s is not positive", request.Quantity), "Quantity must be positive")
+		return
+	}
+
+	ingredient, err := queries.GetVisibleIngredient(c, db.GetVisibleIngredientParams{
+		ID:     request.IngredientID,
+		UserID: userUuid,
+	})
+	if err == pgx.ErrNoRows {
+		sendError(c, http.StatusNotFound, err, "Ingredient not found")
+		return
+	}
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get ingredient")
+		return
+	}
+
+	quantity, err := toIngredientQuantity(request.Quantity, request.Unit, ingredient.Unit)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid unit")
+		return
+	}
+
+	var unit pgtype.Text
+	if request.Unit != "" {
+		unit = getPgtypeText(string(request.Unit))
+	}
+
+	level, err := queries.SetParLevel(c, db.SetParLevelParams{
+		UserID:       userUuid,
+		IngredientID: ingredient.ID,
+		MinQuantity:  quantity,
+		Unit:         unit,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not set par level")
+		return
+	}
+
+	c.JSON(http.StatusOK, level)
+}
+
+/**
+ * /removeParLevel
+ */
+type RemoveParLevelRequest struct {
+	IngredientID uuid.UUID `json:"ingredientId" binding:"required"`
+}
+
+func removeParLevel(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	var request RemoveParLevelRequest
+	if err := c.BindJSON(&request); err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid request body")
+		return
+	}
+
+	level, err := queries.DeleteParLevel(c, db.DeleteParLevelParams{
+		UserID:       userUuid,
+		IngredientID: request.IngredientID,
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, http.StatusNotFound, err, "Par level not found")
+		return
+	}
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not remove par level")
+		return
+	}
+
+	c.JSON(http.StatusOK, level)
+}
+
+/**
+ * /lowStock
+ */
+type LowStockResponse struct {
+	db.GetLowStockRow
+	DisplayShortfall decimal.Decimal     `json:"displayShortfall"`
+	DisplayUnit      models.QuantityUnit `json:"displayUnit"`
+}
+
+func getLowStock(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	low, err := queries.GetLowStock(c, userUuid)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get low stock")
+		return
+	}
+
+	response := make([]LowStockResponse, len(low))
+	for i, item := range low {
+		shortfall, unit := shoppingItemDisplay(item.Shortfall, item.IngredientUnit, item.Unit)
+		response[i] = LowStockResponse{
+			GetLowStockRow:   item,
+			DisplayShortfall: shortfall,
+			DisplayUnit:      unit,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+/**
+ * /restock
+ */
+type RestockRequest struct {
+	// adds to an existing list, otherwise a new list called Name is created
+	ListID *uuid.UUID `json:"listId"`
+	Name   string     `json:"name"`
+}
+
+const defaultRestockListName = "Restock"
+
+// puts everything below par on a shopping list, minus what is already on it
+func restockShoppingList(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	var request RestockRequest
+	if err := c.BindJSON(&request); err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid request body")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Failed to start transaction")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+
+	var list db.Shoppinglist
+	if request.ListID != nil {
+		var ok bool
+		if list, ok = _getOwnShoppingList(c, qtx, userUuid, *request.ListID); !ok {
+			return
+		}
+	} else {
+		name := request.Name
+		if name == "" {
+			name = defaultRestockListName
+		}
+
+		list, err = qtx.CreateShoppingList(ctx, db.CreateShoppingListParams{
+			UserID: userUuid,
+			Name:   name,
+		})
+
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, err, "Could not create shopping list")
+			return
+		}
+	}
+
+	low, err := qtx.GetLowStock(ctx, userUuid)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get low stock")
+		return
+	}
+
+	existing, err := qtx.GetShoppingListItems(ctx, list.ID)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get shopping list items")
+		return
+	}
+
+	pending := map[uuid.UUID]decimal.Decimal{}
+	for _, item := range existing {
+		if !item.Checked {
+			pending[item.IngredientID] = pending[item.IngredientID].Add(item.Quantity)
+		}
+	}
+
+	added := 0
+	for _, item := range low {
+		needed := item.Shortfall.Sub(pending[item.IngredientID])
+		if !needed.IsPositive() {
+			continue
+		}
+
+		_, err := qtx.CreateShoppingListItem(ctx, db.CreateShoppingListItemParams{
+			ListID:       list.ID,
+			IngredientID: item.IngredientID,
+			Quantity:     needed,
+			Unit:         item.Unit,
+		})
+
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, err, "Could not add shopping list item")
+			return
+		}
+
+		added++
+	}
+
+	if err := qtx.TouchShoppingList(ctx, list.ID); err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not update shopping list")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Transaction failed")
+		return
+	}
+
+	log.Printf("Added %d low stock items to shopping list %s\n", added, list.ID)
+	c.JSON(http.StatusOK, list)
+}
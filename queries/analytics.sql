@@ -0,0 +1,343 @@
+-- spend is counted when an entry is created, deleted entries included so
+-- entries merged into others still count; period is a date_trunc field such
+-- as week or month
+-- name: GetSpendByPeriod :many
+SELECT
+  date_trunc(sqlc.arg('period')::text, date_created)::timestamp AS period_start,
+  SUM(price)::numeric AS total_spent,
+  COUNT(*) AS entry_count
+FROM
+  UserItemEntries
+WHERE
+  user_id = sqlc.arg('user_id')
+  AND price IS NOT NULL
+  AND (
+    sqlc.narg('from')::timestamp IS NULL
+    OR date_created >= sqlc.narg('from')::timestamp
+  )
+  AND (
+    sqlc.narg('to')::timestamp IS NULL
+    OR date_created < sqlc.narg('to')::timestamp
+  )
+GROUP BY
+  period_start
+ORDER BY
+  period_start;
+
+-- name: GetSpendByIngredientType :many
+SELECT
+  i.ingredient_type,
+  SUM(ui.price)::numeric AS total_spent,
+  COUNT(*) AS entry_count
+FROM
+  UserItemEntries ui
+  JOIN Ingredients i ON ui.ingredient_id = i.id
+WHERE
+  ui.user_id = sqlc.arg('user_id')
+  AND ui.price IS NOT NULL
+  AND (
+    sqlc.narg('from')::timestamp IS NULL
+    OR ui.date_created >= sqlc.narg('from')::timestamp
+  )
+  AND (
+    sqlc.narg('to')::timestamp IS NULL
+    OR ui.date_created < sqlc.narg('to')::timestamp
+  )
+GROUP BY
+  i.ingredient_type
+ORDER BY
+  total_spent DESC;
+
+-- unit prices are per ground truth unit (ml, g or quarter count)
+-- name: GetSpendByIngredient :many
+SELECT
+  i.id AS ingredient_id,
+  i.name,
+  i.unit,
+  SUM(ui.price)::numeric AS total_spent,
+  SUM(COALESCE(ui.initial_quantity, ui.quantity))::numeric AS total_quantity,
+  COALESCE(
+    SUM(ui.price) / NULLIF(SUM(COALESCE(ui.initial_quantity, ui.quantity)), 0),
+    0
+  )::numeric AS average_unit_price,
+  COUNT(*) AS entry_count
+FROM
+  UserItemEntries ui
+  JOIN Ingredients i ON ui.ingredient_id = i.id
+WHERE
+  ui.user_id = sqlc.arg('user_id')
+  AND ui.price IS NOT NULL
+  AND (
+    sqlc.narg('from')::timestamp IS NULL
+    OR ui.date_created >= sqlc.narg('from')::timestamp
+  )
+  AND (
+    sqlc.narg('to')::timestamp IS NULL
+    OR ui.date_created < sqlc.narg('to')::timestamp
+  )
+GROUP BY
+  i.id,
+  i.name,
+  i.unit
+ORDER BY
+  total_spent DESC;
+
+-- name: GetUnitPriceHistory :many
+SELECT
+  date_trunc(sqlc.arg('period')::text, date_created)::timestamp AS period_start,
+  COALESCE(
+    SUM(price) / NULLIF(SUM(COALESCE(initial_quantity, quantity)), 0),
+    0
+  )::numeric AS average_unit_price,
+  COALESCE(
+    MIN(price / NULLIF(COALESCE(initial_quantity, quantity), 0)),
+    0
+  )::numeric AS min_unit_price,
+  COALESCE(
+    MAX(price / NULLIF(COALESCE(initial_quantity, quantity), 0)),
+    0
+  )::numeric AS max_unit_price
+FROM
+  UserItemEntries
+WHERE
+  user_id = sqlc.arg('user_id')
+  AND ingredient_id = sqlc.arg('ingredient_id')
+  AND price IS NOT NULL
+  AND (
+    sqlc.narg('from')::timestamp IS NULL
+    OR date_created >= sqlc.narg('from')::timestamp
+  )
+  AND (
+    sqlc.narg('to')::timestamp IS NULL
+    OR date_created < sqlc.narg('to')::timestamp
+  )
+GROUP BY
+  period_start
+ORDER BY
+  period_start;
+
+-- name: CreateUserItemDisposal :exec
+INSERT INTO
+  UserItemDisposals (entry_id, user_id, outcome, quantity, cost)
+VALUES
+  (
+    sqlc.arg('entry_id'),
+    sqlc.arg('user_id'),
+    sqlc.arg('outcome'),
+    sqlc.arg('quantity'),
+    sqlc.narg('cost')
+  );
+
+-- undoes disposals recorded after an entry's restored version, since the
+-- restore puts that stock back in the pantry
+-- name: DeleteUserItemDisposalsSince :exec
+DELETE FROM UserItemDisposals
+WHERE
+  entry_id = sqlc.arg('entry_id')
+  AND user_id = sqlc.arg('user_id')
+  AND date_created > sqlc.arg('since');
+
+-- quantity thrown out and its cost, dated by when it was thrown out
+-- name: GetDiscardedCost :many
+SELECT
+  i.id AS ingredient_id,
+  i.name,
+  i.unit,
+  SUM(d.quantity)::numeric AS discarded_quantity,
+  COALESCE(SUM(d.cost), 0)::numeric AS discarded_cost,
+  COUNT(*) AS entry_count
+FROM
+  UserItemDisposals d
+  JOIN UserItemEntries ui ON d.entry_id = ui.id
+  JOIN Ingredients i ON ui.ingredient_id = i.id
+WHERE
+  d.user_id = sqlc.arg('user_id')
+  AND d.outcome IN ('discarded_expired', 'discarded_spoiled')
+  AND (
+    sqlc.narg('from')::timestamp IS NULL
+    OR d.date_created >= sqlc.narg('from')::timestamp
+  )
+  AND (
+    sqlc.narg('to')::timestamp IS NULL
+    OR d.date_created < sqlc.narg('to')::timestamp
+  )
+GROUP BY
+  i.id,
+  i.name,
+  i.unit
+ORDER BY
+  discarded_cost DESC;
+
+-- name: GetWasteByIngredient :many
+SELECT
+  i.id AS ingredient_id,
+  i.name,
+  i.unit,
+  d.outcome,
+  SUM(d.quantity)::numeric AS quantity,
+  COALESCE(SUM(d.cost), 0)::numeric AS cost,
+  COUNT(*) AS disposal_count
+FROM
+  UserItemDisposals d
+  JOIN UserItemEntries ui ON d.entry_id = ui.id
+  JOIN Ingredients i ON ui.ingredient_id = i.id
+WHERE
+  d.user_id = sqlc.arg('user_id')
+  AND d.outcome IN ('discarded_expired', 'discarded_spoiled')
+  AND (
+    sqlc.narg('from')::timestamp IS NULL
+    OR d.date_created >= sqlc.narg('from')::timestamp
+  )
+  AND (
+    sqlc.narg('to')::timestamp IS NULL
+    OR d.date_created < sqlc.narg('to')::timestamp
+  )
+GROUP BY
+  i.id,
+  i.name,
+  i.unit,
+  d.outcome
+ORDER BY
+  cost DESC;
+
+-- waste rolled up through the category tree, so each category's cost covers
+-- every category under it and parent_id lets clients rebuild the tree.
+-- Ingredients without a category are grouped by ingredient_type instead.
+-- name: GetWasteByCategory :many
+WITH RECURSIVE
+  category_tree AS (
+    SELECT
+      id AS ancestor_id,
+      id AS category_id
+    FROM
+      IngredientCategories
+    UNION ALL
+    SELECT
+      t.ancestor_id,
+      c.id
+    FROM
+      category_tree t
+      JOIN IngredientCategories c ON c.parent_id = t.category_id
+  ),
+  waste AS (
+    SELECT
+      i.category_id,
+      i.ingredient_type,
+      d.cost
+    FROM
+      UserItemDisposals d
+      JOIN UserItemEntries ui ON d.entry_id = ui.id
+      JOIN Ingredients i ON ui.ingredient_id = i.id
+    WHERE
+      d.user_id = sqlc.arg('user_id')
+      AND d.outcome IN ('discarded_expired', 'discarded_spoiled')
+      AND (
+        sqlc.narg('from')::timestamp IS NULL
+        OR d.date_created >= sqlc.narg('from')::timestamp
+      )
+      AND (
+        sqlc.narg('to')::timestamp IS NULL
+        OR d.date_created < sqlc.narg('to')::timestamp
+      )
+  ),
+  rolled_up AS (
+    SELECT
+      t.ancestor_id AS category_id,
+      NULL::groc_type AS ingredient_type,
+      w.cost
+    FROM
+      waste w
+      JOIN category_tree t ON t.category_id = w.category_id
+    UNION ALL
+    SELECT
+      NULL::uuid,
+      w.ingredient_type,
+      w.cost
+    FROM
+      waste w
+    WHERE
+      w.category_id IS NULL
+  )
+SELECT
+  r.category_id,
+  ic.parent_id,
+  ic.name AS category_name,
+  r.ingredient_type,
+  COALESCE(SUM(r.cost), 0)::numeric AS cost,
+  COUNT(*) AS disposal_count
+FROM
+  rolled_up r
+  LEFT JOIN IngredientCategories ic ON ic.id = r.category_id
+GROUP BY
+  r.category_id,
+  ic.parent_id,
+  ic.name,
+  r.ingredient_type
+ORDER BY
+  cost DESC;
+
+-- every outcome per month so waste can be compared with what got used
+-- name: GetDisposalsByMonth :many
+SELECT
+  date_trunc('month', date_created)::timestamp AS period_start,
+  outcome,
+  COALESCE(SUM(cost), 0)::numeric AS cost,
+  COUNT(*) AS disposal_count
+FROM
+  UserItemDisposals
+WHERE
+  user_id = sqlc.arg('user_id')
+  AND (
+    sqlc.narg('from')::timestamp IS NULL
+    OR date_created >= sqlc.narg('from')::timestamp
+  )
+  AND (
+    sqlc.narg('to')::timestamp IS NULL
+    OR date_created < sqlc.narg('to')::timestamp
+  )
+GROUP BY
+  period_start,
+  outcome
+ORDER BY
+  period_start,
+  outcome;
+
+-- how many days the user's finished entries of an ingredient lasted, from
+-- purchase (or creation) until the last of it was used up or thrown out.
+-- Entries merged into others have no disposals and are left out. Only entries
+-- kept in the same kind of storage count, resolved like the pantry view
+-- name: GetLearnedShelfLife :one
+WITH
+  lifetimes AS (
+    SELECT
+      EXTRACT(
+        EPOCH
+        FROM
+          MAX(d.date_created) - COALESCE(ui.purchase_date, ui.date_created)
+      ) / 86400 AS days
+    FROM
+      UserItemEntries ui
+      JOIN UserItemDisposals d ON d.entry_id = ui.id
+      JOIN Ingredients i ON ui.ingredient_id = i.id
+      LEFT JOIN StorageLocations sl ON ui.location_id = sl.id
+    WHERE
+      ui.user_id = sqlc.arg('user_id')
+      AND ui.ingredient_id = sqlc.arg('ingredient_id')
+      AND ui.deleted = true
+      AND COALESCE(sl.base_loc, ui.storage_loc, i.storage_loc) = sqlc.arg('storage_loc')::loc_type
+    GROUP BY
+      ui.id,
+      ui.purchase_date,
+      ui.date_created
+  )
+SELECT
+  COUNT(*)::int AS samples,
+  COALESCE(
+    percentile_cont(0.5) WITHIN GROUP (
+      ORDER BY
+        days
+    ),
+    0
+  )::numeric AS median_days
+FROM
+  lifetimes;
@@ -0,0 +1,244 @@
+-- name: GetIngredientCategories :many
+SELECT
+  *
+FROM
+  IngredientCategories
+ORDER BY
+  name;
+
+-- name: CreateIngredientCategory :one
+INSERT INTO
+  IngredientCategories (parent_id, name)
+VALUES
+  (sqlc.narg ('parent_id'), sqlc.arg ('name'))
+RETURNING
+  *;
+
+-- name: CreateRecipeCategoryRequirement :one
+INSERT INTO
+  RecipeCategoryRequirements (
+    recipe_id,
+    category_id,
+    quantity,
+    author_unit_type,
+    author_measure_type
+  )
+VALUES
+  (
+    sqlc.arg ('recipe_id'),
+    sqlc.arg ('category_id'),
+    sqlc.arg ('quantity'),
+    sqlc.arg ('author_unit_type'),
+    sqlc.arg ('author_measure_type')
+  )
+RETURNING
+  *;
+
+-- every requirement of a recipe next to how much of it the user has; a
+-- category requirement counts every ingredient in the category's subtree
+-- that shares the required unit
+-- name: GetRecipePantryMatch :many
+WITH RECURSIVE
+  category_tree AS (
+    SELECT
+      id AS ancestor_id,
+      id AS category_id
+    FROM
+      IngredientCategories
+    UNION ALL
+    SELECT
+      t.ancestor_id,
+      c.id
+    FROM
+      category_tree t
+      JOIN IngredientCategories c ON c.parent_id = t.category_id
+  ),
+  -- unexpired stock only, by the same effective expiry as UserPantryView
+  pantry AS (
+    SELECT
+      ui.ingredient_id,
+      SUM(ui.quantity) AS quantity
+    FROM
+      UserItemEntries ui
+      JOIN Ingredients i ON i.id = ui.ingredient_id
+    WHERE
+      ui.user_id = sqlc.arg ('user_id')::uuid
+      AND ui.deleted = false
+      AND COALESCE(
+        LEAST(
+          ui.expiration_date,
+          ui.opened_at + make_interval(days => i.opened_shelf_life_days)
+        ),
+        'infinity'
+      ) > CURRENT_TIMESTAMP
+    GROUP BY
+      ui.ingredient_id
+  )
+SELECT
+  ri.ingredient_id AS requirement_id,
+  false AS is_category,
+  i.name,
+  ri.quantity AS required_quantity,
+  i.unit,
+  CAST(COALESCE(p.quantity, 0) AS NUMERIC) AS available_quantity
+FROM
+  RecipeIngredients ri
+  JOIN Ingredients i ON i.id = ri.ingredient_id
+  LEFT JOIN pantry p ON p.ingredient_id = ri.ingredient_id
+WHERE
+  ri.recipe_id = sqlc.arg ('recipe_id')::uuid
+UNION ALL
+SELECT
+  rc.category_id AS requirement_id,
+  true AS is_category,
+  c.name,
+  rc.quantity AS required_quantity,
+  rc.author_unit_type AS unit,
+  CAST(
+    COALESCE(
+      (
+        SELECT
+          SUM(p.quantity)
+        FROM
+          category_tree t
+          JOIN Ingredients i ON i.category_id = t.category_id
+          JOIN pantry p ON p.ingredient_id = i.id
+        WHERE
+          t.ancestor_id = rc.category_id
+          AND i.unit = rc.author_unit_type
+      ),
+      0
+    ) AS NUMERIC
+  ) AS available_quantity
+FROM
+  RecipeCategoryRequirements rc
+  JOIN IngredientCategories c ON c.id = rc.category_id
+WHERE
+  rc.recipe_id = sqlc.arg ('recipe_id')::uuid;
+
+-- recipes with at least one requirement, all of them covered by the user's
+-- unexpired stock, or that the user has unexpired leftovers of
+-- name: GetMakeableRecipes :many
+WITH RECURSIVE
+  category_tree AS (
+    SELECT
+      id AS ancestor_id,
+      id AS category_id
+    FROM
+      IngredientCategories
+    UNION ALL
+    SELECT
+      t.ancestor_id,
+      c.id
+    FROM
+      category_tree t
+      JOIN IngredientCategories c ON c.parent_id = t.category_id
+  ),
+  -- unexpired stock only, by the same effective expiry as UserPantryView
+  pantry AS (
+    SELECT
+      ui.ingredient_id,
+      SUM(ui.quantity) AS quantity
+    FROM
+      UserItemEntries ui
+      JOIN Ingredients i ON i.id = ui.ingredient_id
+    WHERE
+      ui.user_id = sqlc.arg ('user_id')::uuid
+      AND ui.deleted = false
+      AND COALESCE(
+        LEAST(
+          ui.expiration_date,
+          ui.opened_at + make_interval(days => i.opened_shelf_life_days)
+        ),
+        'infinity'
+      ) > CURRENT_TIMESTAMP
+    GROUP BY
+      ui.ingredient_id
+  ),
+  leftovers AS (
+    SELECT DISTINCT
+      recipe_id
+    FROM
+      UserItemEntries
+    WHERE
+      user_id = sqlc.arg ('user_id')::uuid
+      AND deleted = false
+      AND recipe_id IS NOT NULL
+      AND (
+        expiration_date IS NULL
+        OR expiration_date > CURRENT_TIMESTAMP
+      )
+  ),
+  requirements AS (
+    SELECT
+      ri.recipe_id,
+      ri.quantity AS required_quantity,
+      COALESCE(p.quantity, 0) AS available_quantity
+    FROM
+      RecipeIngredients ri
+      LEFT JOIN pantry p ON p.ingredient_id = ri.ingredient_id
+    UNION ALL
+    SELECT
+      rc.recipe_id,
+      rc.quantity,
+      COALESCE(
+        (
+          SELECT
+            SUM(p.quantity)
+          FROM
+            category_tree t
+            JOIN Ingredients i ON i.category_id = t.category_id
+            JOIN pantry p ON p.ingredient_id = i.id
+          WHERE
+            t.ancestor_id = rc.category_id
+            AND i.unit = rc.author_unit_type
+        ),
+        0
+      )
+    FROM
+      RecipeCategoryRequirements rc
+  )
+SELECT
+  r.id,
+  r.creator_id,
+  r.date_created,
+  r.name,
+  r.description,
+  r.steps,
+  r.allergens,
+  r.cooking_time,
+  r.serving_size,
+  r.image_path,
+  (l.recipe_id IS NOT NULL) AS has_leftovers
+FROM
+  Recipes r
+  LEFT JOIN leftovers l ON l.recipe_id = r.id
+WHERE
+  l.recipe_id IS NOT NULL
+  OR (
+    EXISTS (
+      SELECT
+        1
+      FROM
+        requirements req
+      WHERE
+        req.recipe_id = r.id
+    )
+    AND NOT EXISTS (
+      SELECT
+        1
+      FROM
+        requirements req
+      WHERE
+        req.recipe_id = r.id
+        AND req.available_quantity < req.required_quantity
+    )
+  );
+
+-- name: GetIngredientCategory :one
+SELECT
+  *
+FROM
+  IngredientCategories
+WHERE
+  id = sqlc.arg('id');
@@ -0,0 +1,101 @@
+-- name: CreateEntryHistory :exec
+INSERT INTO
+  UserItemEntryHistory (
+    entry_id,
+    user_id,
+    actor_id,
+    action,
+    old_value,
+    new_value,
+    device_id,
+    source
+  )
+VALUES
+  (
+    sqlc.arg('entry_id'),
+    sqlc.arg('user_id'),
+    sqlc.arg('actor_id'),
+    sqlc.arg('action'),
+    sqlc.narg('old_value'),
+    sqlc.narg('new_value'),
+    sqlc.narg('device_id'),
+    sqlc.arg('source')
+  );
+
+-- name: GetEntryHistory :many
+SELECT
+  *
+FROM
+  UserItemEntryHistory
+WHERE
+  entry_id = sqlc.arg('entry_id')
+  AND user_id = sqlc.arg('user_id')
+ORDER BY
+  date_created DESC;
+
+-- name: GetUserEntryHistory :many
+SELECT
+  *
+FROM
+  UserItemEntryHistory
+WHERE
+  user_id = sqlc.arg('user_id')
+ORDER BY
+  date_created DESC
+LIMIT
+  sqlc.arg('limit');
+
+-- name: GetEntryHistoryRecord :one
+SELECT
+  *
+FROM
+  UserItemEntryHistory
+WHERE
+  id = sqlc.arg('id')
+  AND user_id = sqlc.arg('user_id');
+
+-- unlike UpdateUserItemEntry this also reaches deleted entries so they can be
+-- brought back. Locations and recipes removed since the snapshot are left
+-- unset rather than pointed at rows that no longer exist.
+-- name: RestoreUserItemEntry :one
+UPDATE
+  UserItemEntries
+SET
+  ingredient_id = sqlc.narg('ingredient_id'),
+  recipe_id = (
+    SELECT
+      r.id
+    FROM
+      Recipes r
+    WHERE
+      r.id = sqlc.narg('recipe_id')
+  ),
+  quantity = sqlc.arg('quantity'),
+  initial_quantity = sqlc.narg('initial_quantity'),
+  price = sqlc.arg('price'),
+  unit_cost = sqlc.narg('unit_cost'),
+  expiration_date = sqlc.narg('expiration_date'),
+  location_id = (
+    SELECT
+      sl.id
+    FROM
+      StorageLocations sl
+    WHERE
+      sl.id = sqlc.narg('location_id')
+      AND sl.user_id = sqlc.arg('user_id')
+  ),
+  storage_loc = sqlc.narg('storage_loc'),
+  entered_unit = sqlc.narg('entered_unit'),
+  brand = sqlc.narg('brand'),
+  store = sqlc.narg('store'),
+  purchase_date = sqlc.narg('purchase_date'),
+  note = sqlc.narg('note'),
+  tags = COALESCE(sqlc.narg('tags')::text[], '{}'),
+  opened_at = sqlc.narg('opened_at'),
+  deleted = sqlc.arg('deleted'),
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = sqlc.arg('id')
+  AND user_id = sqlc.arg('user_id')
+RETURNING
+  *;
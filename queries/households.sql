@@ -0,0 +1,55 @@
+-- name: CreateHousehold :one
+INSERT INTO
+  Households (creator_id, name, date_created)
+VALUES
+  (sqlc.arg ('creator_id'), sqlc.arg ('name'), CURRENT_DATE)
+RETURNING
+  *;
+
+-- name: AddHouseholdMember :exec
+INSERT INTO
+  HouseholdMembers (household_id, user_id)
+VALUES
+  (sqlc.arg ('household_id'), sqlc.arg ('user_id'))
+ON CONFLICT DO NOTHING;
+
+-- name: RemoveHouseholdMember :exec
+DELETE FROM HouseholdMembers
+WHERE
+  household_id = sqlc.arg ('household_id')
+  AND user_id = sqlc.arg ('user_id');
+
+-- name: GetUserHouseholds :many
+SELECT
+  h.id,
+  h.creator_id,
+  h.name,
+  h.date_created
+FROM
+  Households h
+  JOIN HouseholdMembers m ON m.household_id = h.id
+WHERE
+  m.user_id = sqlc.arg ('user_id');
+
+-- name: GetHouseholdMembers :many
+SELECT
+  u.id,
+  u.email,
+  u.name
+FROM
+  Users u
+  JOIN HouseholdMembers m ON m.user_id = u.id
+WHERE
+  m.household_id = sqlc.arg ('household_id');
+
+-- name: IsHouseholdMember :one
+SELECT
+  EXISTS (
+    SELECT
+      1
+    FROM
+      HouseholdMembers
+    WHERE
+      household_id = sqlc.arg ('household_id')
+      AND user_id = sqlc.arg ('user_id')
+  ) AS is_member;
@@ -1,8 +1,61 @@
+-- only ingredients the user may see: the global catalog, their own and
+-- ones shared with a household they belong to
 -- name: GetIngredients :many
 SELECT
   *
 FROM
-  Ingredients;
+  Ingredients
+WHERE
+  deleted = false
+  AND (
+    visibility = 'global'
+    OR creator_id = sqlc.arg ('user_id')::uuid
+    OR (
+      visibility = 'household'
+      AND household_id IN (
+        SELECT
+          household_id
+        FROM
+          HouseholdMembers
+        WHERE
+          user_id = sqlc.arg ('user_id')::uuid
+      )
+    )
+  );
+
+-- name: GetIngredient :one
+SELECT
+  *
+FROM
+  Ingredients
+WHERE
+  id = sqlc.arg('id');
+
+-- same rule as GetIngredients, for anything that lets a user act on the
+-- ingredient rather than just read an entry that already points at it
+-- name: GetVisibleIngredient :one
+SELECT
+  *
+FROM
+  Ingredients
+WHERE
+  id = sqlc.arg ('id')
+  AND deleted = false
+  AND (
+    visibility = 'global'
+    OR creator_id = sqlc.arg ('user_id')::uuid
+    OR (
+      visibility = 'household'
+      AND household_id IN (
+        SELECT
+          household_id
+        FROM
+          HouseholdMembers
+        WHERE
+          user_id = sqlc.arg ('user_id')::uuid
+      )
+    )
+  );
 
 -- name: GetIngredientsByIds :many
 SELECT
@@ -12,6 +65,29 @@ FROM
 WHERE
   id = ANY(sqlc.arg('ids')::uuid[]);
 
+-- name: GetVisibleIngredientsByIds :many
+SELECT
+  *
+FROM
+  Ingredients
+WHERE
+  id = ANY(sqlc.arg('ids')::uuid[])
+  AND (
+    visibility = 'global'
+    OR creator_id = sqlc.arg ('user_id')::uuid
+    OR (
+      visibility = 'household'
+      AND household_id IN (
+        SELECT
+          household_id
+        FROM
+          HouseholdMembers
+        WHERE
+          user_id = sqlc.arg ('user_id')::uuid
+      )
+    )
+  );
+
 -- WHERE
 --   name = sqlc.arg('name');
 -- date created is current date
@@ -23,7 +99,10 @@ INSERT INTO
     unit,
     storage_loc,
     ingredient_type,
-    image_path
+    image_path,
+    visibility,
+    household_id,
+    opened_shelf_life_days
   )
 VALUES
   (
@@ -32,7 +111,10 @@ VALUES
     sqlc.arg ('unit'),
     sqlc.arg ('storage_loc'),
     sqlc.arg ('ingredient_type'),
-    sqlc.narg ('image_path')
+    sqlc.narg ('image_path'),
+    sqlc.arg ('visibility'),
+    sqlc.narg ('household_id'),
+    sqlc.narg ('opened_shelf_life_days')
   )
 RETURNING
   *;
@@ -43,9 +125,185 @@ SELECT
 FROM
   Ingredients
 WHERE
-  name ILIKE '%' || sqlc.arg('name') || '%';
-  
--- name: DeleteIngredient :exec
-DELETE FROM Ingredients
+  deleted = false
+  AND name ILIKE '%' || sqlc.arg('name') || '%'
+  AND (
+    visibility = 'global'
+    OR creator_id = sqlc.arg ('user_id')::uuid
+    OR (
+      visibility = 'household'
+      AND household_id IN (
+        SELECT
+          household_id
+        FROM
+          HouseholdMembers
+        WHERE
+          user_id = sqlc.arg ('user_id')::uuid
+      )
+    )
+  );
+
+-- catalog rows are matched on normalized name so re-running a seed is idempotent
+-- name: UpsertCatalogIngredient :one
+INSERT INTO
+  Ingredients (
+    name,
+    unit,
+    storage_loc,
+    ingredient_type,
+    aliases,
+    shelf_life_days,
+    opened_shelf_life_days,
+    visibility
+  )
+VALUES
+  (
+    sqlc.arg ('name'),
+    sqlc.arg ('unit'),
+    sqlc.arg ('storage_loc'),
+    sqlc.arg ('ingredient_type'),
+    sqlc.arg ('aliases'),
+    sqlc.narg ('shelf_life_days'),
+    sqlc.narg ('opened_shelf_life_days'),
+    'global'
+  )
+ON CONFLICT (lower(btrim(name)))
+WHERE
+  visibility = 'global' DO
+UPDATE
+SET
+  name = EXCLUDED.name,
+  unit = EXCLUDED.unit,
+  storage_loc = EXCLUDED.storage_loc,
+  ingredient_type = EXCLUDED.ingredient_type,
+  aliases = EXCLUDED.aliases,
+  shelf_life_days = EXCLUDED.shelf_life_days,
+  opened_shelf_life_days = EXCLUDED.opened_shelf_life_days,
+  deleted = false,
+  last_modified = CURRENT_TIMESTAMP
+RETURNING
+  *;
+
+-- all fields are optional except for id
+-- name: UpdateIngredient :one
+UPDATE Ingredients
+SET
+  name = COALESCE(sqlc.narg ('name'), name),
+  unit = COALESCE(sqlc.narg ('unit')::unit_type, unit),
+  storage_loc = COALESCE(sqlc.narg ('storage_loc')::loc_type, storage_loc),
+  ingredient_type = COALESCE(
+    sqlc.narg ('ingredient_type')::groc_type,
+    ingredient_type
+  ),
+  image_path = COALESCE(sqlc.narg ('image_path'), image_path),
+  category_id = COALESCE(sqlc.narg ('category_id'), category_id),
+  opened_shelf_life_days = COALESCE(
+    sqlc.narg ('opened_shelf_life_days'),
+    opened_shelf_life_days
+  ),
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = sqlc.arg ('id')
+  AND deleted = false
+RETURNING
+  *;
+
+-- name: SetIngredientVisibility :one
+UPDATE Ingredients
+SET
+  visibility = sqlc.arg ('visibility'),
+  household_id = sqlc.narg ('household_id'),
+  last_modified = CURRENT_TIMESTAMP
 WHERE
-  id = sqlc.arg('id');
\ No newline at end of file
+  id = sqlc.arg ('id')
+RETURNING
+  *;
+
+-- keeps the row so existing recipes and pantry entries stay valid
+-- name: SoftDeleteIngredient :one
+UPDATE Ingredients
+SET
+  deleted = true,
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = sqlc.arg ('id')
+  AND deleted = false
+RETURNING
+  *;
+
+-- counts recipes and pantry entries pointing at an ingredient, split by
+-- whether they belong to someone other than the given user
+-- name: CountIngredientReferences :one
+SELECT
+  CAST(
+    (
+      SELECT
+        COUNT(*)
+      FROM
+        RecipeIngredients ri
+        JOIN Recipes r ON ri.recipe_id = r.id
+      WHERE
+        ri.ingredient_id = sqlc.arg ('id')::uuid
+        AND r.creator_id IS DISTINCT FROM sqlc.arg ('user_id')::uuid
+    ) + (
+      SELECT
+        COUNT(*)
+      FROM
+        UserItemEntries ui
+      WHERE
+        ui.ingredient_id = sqlc.arg ('id')::uuid
+        AND ui.user_id IS DISTINCT FROM sqlc.arg ('user_id')::uuid
+    ) AS BIGINT
+  ) AS other_references,
+  CAST(
+    (
+      SELECT
+        COUNT(*)
+      FROM
+        RecipeIngredients ri
+      WHERE
+        ri.ingredient_id = sqlc.arg ('id')::uuid
+    ) + (
+      SELECT
+        COUNT(*)
+      FROM
+        UserItemEntries ui
+      WHERE
+        ui.ingredient_id = sqlc.arg ('id')::uuid
+    ) AS BIGINT
+  ) AS total_references;
+
+-- name: CreateIngredientPromotion :one
+INSERT INTO
+  IngredientPromotions (ingredient_id, requester_id, note)
+VALUES
+  (
+    sqlc.arg ('ingredient_id'),
+    sqlc.arg ('requester_id'),
+    sqlc.narg ('note')
+  )
+RETURNING
+  *;
+
+-- name: GetPendingIngredientPromotions :many
+SELECT
+  *
+FROM
+  IngredientPromotions
+WHERE
+  status = 'pending'
+ORDER BY
+  date_created;
+
+-- only pending requests can be reviewed, so a second review returns no rows
+-- name: ReviewIngredientPromotion :one
+UPDATE IngredientPromotions
+SET
+  status = sqlc.arg ('status'),
+  reviewer_id = sqlc.arg ('reviewer_id'),
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = sqlc.arg ('id')
+  AND status = 'pending'
+RETURNING
+  *;
@@ -0,0 +1,94 @@
+-- filters are skipped when null; sort is one of expiry, name or quantity.
+-- tag keeps rows where any of the grouped entries carries it
+-- name: FilterUserPantry :many
+SELECT
+  *
+FROM
+  UserPantryView
+WHERE
+  user_id = sqlc.arg('user_id')
+  AND (
+    sqlc.narg('storage_loc')::loc_type IS NULL
+    OR storage_loc = sqlc.narg('storage_loc')::loc_type
+  )
+  AND (
+    sqlc.narg('ingredient_type')::groc_type IS NULL
+    OR ingredient_type = sqlc.narg('ingredient_type')::groc_type
+  )
+  AND (
+    sqlc.narg('name')::text IS NULL
+    OR ingredient_name ILIKE '%' || sqlc.narg('name')::text || '%'
+  )
+  AND (
+    sqlc.narg('expires_after')::timestamp IS NULL
+    OR expiration_date >= sqlc.narg('expires_after')::timestamp
+  )
+  AND (
+    sqlc.narg('expires_before')::timestamp IS NULL
+    OR expiration_date < sqlc.narg('expires_before')::timestamp
+  )
+  AND (
+    sqlc.narg('tag')::text IS NULL
+    OR EXISTS (
+      SELECT
+        1
+      FROM
+        UserItemEntries ui
+      WHERE
+        ui.user_id = UserPantryView.user_id
+        AND ui.ingredient_id IS NOT DISTINCT FROM UserPantryView.ingredient_id
+        AND ui.recipe_id IS NOT DISTINCT FROM UserPantryView.recipe_id
+        AND ui.location_id IS NOT DISTINCT FROM UserPantryView.location_id
+        AND ui.deleted = false
+        AND sqlc.narg('tag')::text = ANY (ui.tags)
+    )
+  )
+ORDER BY
+  CASE WHEN sqlc.arg('sort')::text = 'expiry' THEN expiration_date END ASC NULLS LAST,
+  CASE WHEN sqlc.arg('sort')::text = 'quantity' THEN quantity END DESC,
+  ingredient_name;
+
+-- one row per live entry with what an export needs to be read by people
+-- name: GetPantryExport :many
+SELECT
+  ui.id,
+  i.name AS ingredient_name,
+  i.unit AS ingredient_unit,
+  ui.quantity,
+  ui.entered_unit,
+  COALESCE(sl.name, ui.storage_loc::text, i.storage_loc::text)::text AS location,
+  ui.price,
+  ui.initial_quantity,
+  ui.unit_cost,
+  ui.expiration_date
+FROM
+  UserItemEntries ui
+  JOIN Ingredients i ON ui.ingredient_id = i.id
+  LEFT JOIN StorageLocations sl ON ui.location_id = sl.id
+WHERE
+  ui.user_id = sqlc.arg('user_id')
+  AND ui.deleted = false
+ORDER BY
+  i.name,
+  ui.expiration_date NULLS LAST;
+
+-- how much of each ingredient was used since a time, from disposals marked
+-- consumed so discards, stocktake corrections and restores do not count.
+-- observed_since is when the oldest of the used entries was added, if that is
+-- inside the window, so ingredients bought recently are not averaged over the
+-- whole of it
+-- name: GetConsumptionHistory :many
+SELECT
+  ui.ingredient_id,
+  SUM(d.quantity)::numeric AS consumed,
+  GREATEST(MIN(ui.date_created), sqlc.arg('since')::timestamp)::timestamp AS observed_since
+FROM
+  UserItemDisposals d
+  JOIN UserItemEntries ui ON d.entry_id = ui.id
+WHERE
+  d.user_id = sqlc.arg('user_id')
+  AND d.outcome = 'consumed'
+  AND d.date_created >= sqlc.arg('since')
+  AND ui.ingredient_id IS NOT NULL
+GROUP BY
+  ui.ingredient_id;
@@ -0,0 +1,76 @@
+-- name: GetParLevels :many
+SELECT
+  p.ingredient_id,
+  i.name,
+  i.unit AS ingredient_unit,
+  p.min_quantity,
+  p.unit,
+  p.last_modified
+FROM
+  ParLevels p
+  JOIN Ingredients i ON p.ingredient_id = i.id
+WHERE
+  p.user_id = sqlc.arg('user_id')
+ORDER BY
+  i.name;
+
+-- name: SetParLevel :one
+INSERT INTO
+  ParLevels (user_id, ingredient_id, min_quantity, unit)
+VALUES
+  (
+    sqlc.arg('user_id'),
+    sqlc.arg('ingredient_id'),
+    sqlc.arg('min_quantity'),
+    sqlc.narg('unit')
+  )
+ON CONFLICT (user_id, ingredient_id) DO UPDATE
+SET
+  min_quantity = EXCLUDED.min_quantity,
+  unit = EXCLUDED.unit,
+  last_modified = CURRENT_TIMESTAMP
+RETURNING
+  *;
+
+-- name: DeleteParLevel :one
+DELETE FROM
+  ParLevels
+WHERE
+  user_id = sqlc.arg('user_id')
+  AND ingredient_id = sqlc.arg('ingredient_id')
+RETURNING
+  *;
+
+-- ingredients whose pantry total is under the user's par level
+-- name: GetLowStock :many
+WITH
+  on_hand AS (
+    SELECT
+      ingredient_id,
+      SUM(quantity) AS quantity
+    FROM
+      UserPantryView
+    WHERE
+      user_id = sqlc.arg('user_id')
+    GROUP BY
+      ingredient_id
+  )
+SELECT
+  p.ingredient_id,
+  i.name,
+  i.unit AS ingredient_unit,
+  i.ingredient_type,
+  p.min_quantity,
+  p.unit,
+  COALESCE(h.quantity, 0)::numeric AS on_hand,
+  (p.min_quantity - COALESCE(h.quantity, 0))::numeric AS shortfall
+FROM
+  ParLevels p
+  JOIN Ingredients i ON p.ingredient_id = i.id
+  LEFT JOIN on_hand h ON p.ingredient_id = h.ingredient_id
+WHERE
+  p.user_id = sqlc.arg('user_id')
+  AND COALESCE(h.quantity, 0) < p.min_quantity
+ORDER BY
+  i.ingredient_type,
+  i.name;
@@ -0,0 +1,263 @@
+-- name: CreateShoppingList :one
+INSERT INTO
+  ShoppingLists (user_id, name)
+VALUES
+  (sqlc.arg('user_id'), sqlc.arg('name'))
+RETURNING
+  *;
+
+-- name: GetShoppingLists :many
+SELECT
+  *
+FROM
+  ShoppingLists
+WHERE
+  user_id = sqlc.arg('user_id')
+ORDER BY
+  last_modified DESC;
+
+-- name: GetShoppingList :one
+SELECT
+  *
+FROM
+  ShoppingLists
+WHERE
+  id = sqlc.arg('id')
+  AND user_id = sqlc.arg('user_id');
+
+-- name: DeleteShoppingList :one
+DELETE FROM
+  ShoppingLists
+WHERE
+  id = sqlc.arg('id')
+  AND user_id = sqlc.arg('user_id')
+RETURNING
+  *;
+
+-- name: TouchShoppingList :exec
+UPDATE
+  ShoppingLists
+SET
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = sqlc.arg('id');
+
+-- ordered by ingredient_type so items come out in aisle order
+-- name: GetShoppingListItems :many
+SELECT
+  sli.id,
+  sli.list_id,
+  sli.ingredient_id,
+  sli.quantity,
+  sli.unit,
+  sli.note,
+  sli.checked,
+  sli.last_modified,
+  i.name AS ingredient_name,
+  i.unit AS ingredient_unit,
+  i.ingredient_type
+FROM
+  ShoppingListItems sli
+  JOIN Ingredients i ON sli.ingredient_id = i.id
+WHERE
+  sli.list_id = sqlc.arg('list_id')
+ORDER BY
+  i.ingredient_type,
+  i.name;
+
+-- name: CreateShoppingListItem :one
+INSERT INTO
+  ShoppingListItems (list_id, ingredient_id, quantity, unit, note)
+VALUES
+  (
+    sqlc.arg('list_id'),
+    sqlc.arg('ingredient_id'),
+    sqlc.arg('quantity'),
+    sqlc.narg('unit'),
+    sqlc.narg('note')
+  )
+RETURNING
+  *;
+
+-- only reaches items on lists owned by user_id
+-- name: GetShoppingListItem :one
+SELECT
+  sli.*
+FROM
+  ShoppingListItems sli
+  JOIN ShoppingLists sl ON sli.list_id = sl.id
+WHERE
+  sli.id = sqlc.arg('id')
+  AND sl.user_id = sqlc.arg('user_id');
+
+-- name: UpdateShoppingListItem :one
+UPDATE
+  ShoppingListItems
+SET
+  quantity = sqlc.arg('quantity'),
+  unit = sqlc.narg('unit'),
+  note = sqlc.narg('note'),
+  checked = sqlc.arg('checked'),
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = sqlc.arg('id')
+RETURNING
+  *;
+
+-- name: DeleteShoppingListItem :exec
+DELETE FROM
+  ShoppingListItems
+WHERE
+  id = sqlc.arg('id');
+
+-- name: GetCheckedShoppingListItems :many
+SELECT
+  *
+FROM
+  ShoppingListItems
+WHERE
+  list_id = sqlc.arg('list_id')
+  AND checked = true;
+
+-- name: DeleteCheckedShoppingListItems :exec
+DELETE FROM
+  ShoppingListItems
+WHERE
+  list_id = sqlc.arg('list_id')
+  AND checked = true;
+
+-- what the recipes need beyond what is already in the pantry, in each
+-- ingredient's ground truth unit. A category requirement that falls short is
+-- bought as one ingredient from the category with the required unit, one the
+-- user has kept before if there is one, and only stock the exact ingredient
+-- needs leave over counts towards it. An ingredient can come back once for
+-- itself and once for each category it stands in for.
+-- name: GetRecipeShortfall :many
+WITH RECURSIVE
+  category_tree AS (
+    SELECT
+      id AS ancestor_id,
+      id AS category_id
+    FROM
+      IngredientCategories
+    UNION ALL
+    SELECT
+      t.ancestor_id,
+      c.id
+    FROM
+      category_tree t
+      JOIN IngredientCategories c ON c.parent_id = t.category_id
+  ),
+  stocked AS (
+    SELECT
+      ingredient_id,
+      SUM(quantity) AS quantity
+    FROM
+      UserItemEntries
+    WHERE
+      user_id = sqlc.arg('user_id')::uuid
+      AND deleted = false
+    GROUP BY
+      ingredient_id
+  ),
+  needed AS (
+    SELECT
+      ingredient_id,
+      SUM(quantity) AS quantity
+    FROM
+      RecipeIngredients
+    WHERE
+      recipe_id = ANY (sqlc.arg('recipe_ids')::uuid[])
+    GROUP BY
+      ingredient_id
+  ),
+  category_needed AS (
+    SELECT
+      rc.category_id,
+      rc.author_unit_type AS unit,
+      SUM(rc.quantity) - COALESCE(
+        (
+          SELECT
+            SUM(GREATEST(s.quantity - COALESCE(n.quantity, 0), 0))
+          FROM
+            category_tree t
+            JOIN Ingredients i ON i.category_id = t.category_id
+            JOIN stocked s ON s.ingredient_id = i.id
+            LEFT JOIN needed n ON n.ingredient_id = i.id
+          WHERE
+            t.ancestor_id = rc.category_id
+            AND i.unit = rc.author_unit_type
+        ),
+        0
+      ) AS quantity
+    FROM
+      RecipeCategoryRequirements rc
+    WHERE
+      rc.recipe_id = ANY (sqlc.arg('recipe_ids')::uuid[])
+    GROUP BY
+      rc.category_id,
+      rc.author_unit_type
+  ),
+  shortfall AS (
+    SELECT
+      n.ingredient_id,
+      n.quantity - COALESCE(s.quantity, 0) AS quantity
+    FROM
+      needed n
+      LEFT JOIN stocked s ON n.ingredient_id = s.ingredient_id
+    WHERE
+      n.quantity > COALESCE(s.quantity, 0)
+    UNION ALL
+    SELECT
+      (
+        SELECT
+          i.id
+        FROM
+          category_tree t
+          JOIN Ingredients i ON i.category_id = t.category_id
+        WHERE
+          t.ancestor_id = cn.category_id
+          AND i.unit = cn.unit
+          AND i.deleted = false
+          AND (
+            i.visibility = 'global'
+            OR i.creator_id = sqlc.arg('user_id')::uuid
+            OR (
+              i.visibility = 'household'
+              AND i.household_id IN (
+                SELECT
+                  household_id
+                FROM
+                  HouseholdMembers
+                WHERE
+                  user_id = sqlc.arg('user_id')::uuid
+              )
+            )
+          )
+        ORDER BY
+          EXISTS (
+            SELECT
+              1
+            FROM
+              UserItemEntries ui
+            WHERE
+              ui.ingredient_id = i.id
+              AND ui.user_id = sqlc.arg('user_id')::uuid
+          ) DESC,
+          i.name
+        LIMIT
+          1
+      ),
+      cn.quantity
+    FROM
+      category_needed cn
+    WHERE
+      cn.quantity > 0
+  )
+SELECT
+  ingredient_id,
+  quantity::numeric AS quantity
+FROM
+  shortfall
+WHERE
+  ingredient_id IS NOT NULL;
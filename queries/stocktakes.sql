@@ -0,0 +1,135 @@
+-- name: CreateStocktake :one
+INSERT INTO
+  Stocktakes (user_id, location_id, storage_loc)
+VALUES
+  (
+    sqlc.arg('user_id'),
+    sqlc.narg('location_id'),
+    sqlc.narg('storage_loc')
+  )
+RETURNING
+  *;
+
+-- name: GetStocktakes :many
+SELECT
+  *
+FROM
+  Stocktakes
+WHERE
+  user_id = sqlc.arg('user_id')
+ORDER BY
+  date_created DESC;
+
+-- name: GetStocktake :one
+SELECT
+  *
+FROM
+  Stocktakes
+WHERE
+  id = sqlc.arg('id')
+  AND user_id = sqlc.arg('user_id');
+
+-- name: SetStocktakeCount :one
+INSERT INTO
+  StocktakeCounts (stocktake_id, ingredient_id, quantity)
+VALUES
+  (
+    sqlc.arg('stocktake_id'),
+    sqlc.arg('ingredient_id'),
+    sqlc.arg('quantity')
+  )
+ON CONFLICT (stocktake_id, ingredient_id) DO UPDATE
+SET
+  quantity = EXCLUDED.quantity,
+  last_modified = CURRENT_TIMESTAMP
+RETURNING
+  *;
+
+-- only open stocktakes can be applied, so applying twice returns no rows
+-- name: MarkStocktakeApplied :one
+UPDATE
+  Stocktakes
+SET
+  applied_at = CURRENT_TIMESTAMP
+WHERE
+  id = sqlc.arg('id')
+  AND user_id = sqlc.arg('user_id')
+  AND applied_at IS NULL
+RETURNING
+  *;
+
+-- locks the live entries the stocktake covers, first-expiring first
+-- name: GetStocktakeEntries :many
+SELECT
+  ui.*
+FROM
+  UserItemEntries ui
+  JOIN Ingredients i ON ui.ingredient_id = i.id
+  JOIN Stocktakes s ON s.user_id = ui.user_id
+WHERE
+  s.id = sqlc.arg('stocktake_id')
+  AND ui.deleted = false
+  AND (
+    ui.location_id = s.location_id
+    OR (
+      s.location_id IS NULL
+      AND ui.location_id IS NULL
+      AND COALESCE(ui.storage_loc, i.storage_loc) = s.storage_loc
+    )
+  )
+ORDER BY
+  LEAST(
+    ui.expiration_date,
+    ui.opened_at + make_interval(days => i.opened_shelf_life_days)
+  ) NULLS LAST,
+  ui.last_modified
+FOR UPDATE OF ui;
+
+-- expected quantities from the entries against what was counted; ingredients
+-- that were not counted have a null counted and difference
+-- name: GetStocktakeDiff :many
+WITH
+  expected AS (
+    SELECT
+      ui.ingredient_id,
+      SUM(ui.quantity) AS quantity
+    FROM
+      UserItemEntries ui
+      JOIN Ingredients i ON ui.ingredient_id = i.id
+      JOIN Stocktakes s ON s.user_id = ui.user_id
+    WHERE
+      s.id = sqlc.arg('stocktake_id')
+      AND ui.deleted = false
+      AND (
+        ui.location_id = s.location_id
+        OR (
+          s.location_id IS NULL
+          AND ui.location_id IS NULL
+          AND COALESCE(ui.storage_loc, i.storage_loc) = s.storage_loc
+        )
+      )
+    GROUP BY
+      ui.ingredient_id
+  ),
+  counted AS (
+    SELECT
+      ingredient_id,
+      quantity
+    FROM
+      StocktakeCounts
+    WHERE
+      stocktake_id = sqlc.arg('stocktake_id')
+  )
+SELECT
+  i.id AS ingredient_id,
+  i.name AS ingredient_name,
+  i.unit,
+  COALESCE(e.quantity, 0)::numeric AS expected,
+  c.quantity AS counted,
+  (c.quantity - COALESCE(e.quantity, 0))::numeric AS difference
+FROM
+  expected e
+  FULL JOIN counted c ON c.ingredient_id = e.ingredient_id
+  JOIN Ingredients i ON i.id = COALESCE(e.ingredient_id, c.ingredient_id)
+ORDER BY
+  i.name;
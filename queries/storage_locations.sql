@@ -0,0 +1,57 @@
+-- name: GetStorageLocations :many
+SELECT
+  *
+FROM
+  StorageLocations
+WHERE
+  user_id = sqlc.arg('user_id')
+ORDER BY
+  name;
+
+-- name: GetStorageLocation :one
+SELECT
+  *
+FROM
+  StorageLocations
+WHERE
+  id = sqlc.arg('id')
+  AND user_id = sqlc.arg('user_id');
+
+-- name: CreateStorageLocation :one
+INSERT INTO
+  StorageLocations (user_id, name, base_loc)
+VALUES
+  (
+    sqlc.arg('user_id'),
+    sqlc.arg('name'),
+    sqlc.arg('base_loc')
+  )
+RETURNING
+  *;
+
+-- entries in the location fall back to their ingredient's storage_loc
+-- name: DeleteStorageLocation :one
+DELETE FROM
+  StorageLocations
+WHERE
+  id = sqlc.arg('id')
+  AND user_id = sqlc.arg('user_id')
+RETURNING
+  *;
+
+-- a null location_id with a null storage_loc moves the entry back to its
+-- ingredient's storage_loc
+-- name: MoveUserItemEntry :one
+UPDATE
+  UserItemEntries
+SET
+  location_id = sqlc.narg('location_id'),
+  storage_loc = sqlc.narg('storage_loc'),
+  expiration_date = sqlc.narg('expiration_date'),
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = sqlc.arg('id')
+  AND user_id = sqlc.arg('user_id')
+  AND deleted = false
+RETURNING
+  *;
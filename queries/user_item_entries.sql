@@ -4,4 +4,122 @@ SELECT
 FROM
   UserItemEntries
 WHERE
-  user_id = sqlc.arg('user_id');
\ No newline at end of file
+  user_id = sqlc.arg('user_id');
+-- name: GetUserItemEntry :one
+SELECT
+  *
+FROM
+  UserItemEntries
+WHERE
+  id = sqlc.arg('id')
+  AND user_id = sqlc.arg('user_id');
+
+-- name: ListUserItemEntries :many
+SELECT
+  *
+FROM
+  UserItemEntries
+WHERE
+  user_id = sqlc.arg('user_id')
+  AND (
+    sqlc.arg('include_deleted')::boolean
+    OR deleted = false
+  )
+ORDER BY
+  expiration_date NULLS LAST,
+  last_modified;
+
+-- locks the user's live entries for an ingredient, first-expiring first,
+-- counting opened entries by when they go off once opened
+-- name: GetConsumableUserItemEntries :many
+SELECT
+  ui.*
+FROM
+  UserItemEntries ui
+  JOIN Ingredients i ON ui.ingredient_id = i.id
+WHERE
+  ui.user_id = sqlc.arg('user_id')
+  AND ui.ingredient_id = sqlc.arg('ingredient_id')
+  AND ui.deleted = false
+ORDER BY
+  LEAST(
+    ui.expiration_date,
+    ui.opened_at + make_interval(days => i.opened_shelf_life_days)
+  ) NULLS LAST,
+  ui.last_modified
+FOR UPDATE OF ui;
+
+-- live sealed entries an ingredient's new stock could be merged into, oldest
+-- first; opened entries keep their own expiry so they are never merged
+-- name: GetMergeCandidates :many
+SELECT
+  *
+FROM
+  UserItemEntries
+WHERE
+  user_id = sqlc.arg('user_id')
+  AND ingredient_id = sqlc.arg('ingredient_id')
+  AND deleted = false
+  AND opened_at IS NULL
+ORDER BY
+  date_created,
+  id
+FOR UPDATE;
+
+-- live sealed entries of every ingredient, locked for compaction
+-- name: GetCompactableUserItemEntries :many
+SELECT
+  *
+FROM
+  UserItemEntries
+WHERE
+  user_id = sqlc.arg('user_id')
+  AND deleted = false
+  AND opened_at IS NULL
+ORDER BY
+  ingredient_id,
+  date_created,
+  id
+FOR UPDATE;
+
+-- price and initial_quantity are left alone so spend is still counted per
+-- purchase
+-- name: MergeUserItemEntry :one
+UPDATE
+  UserItemEntries
+SET
+  quantity = sqlc.arg('quantity'),
+  unit_cost = sqlc.narg('unit_cost'),
+  expiration_date = sqlc.narg('expiration_date'),
+  tags = sqlc.arg('tags'),
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = sqlc.arg('id')
+  AND user_id = sqlc.arg('user_id')
+  AND deleted = false
+RETURNING
+  *;
+
+-- name: CreateLeftoverEntry :one
+INSERT INTO
+  UserItemEntries (
+    user_id,
+    recipe_id,
+    quantity,
+    initial_quantity,
+    expiration_date,
+    storage_loc,
+    entered_unit
+  )
+VALUES
+  (
+    sqlc.arg('user_id'),
+    sqlc.arg('recipe_id'),
+    sqlc.arg('quantity'),
+    sqlc.arg('quantity'),
+    sqlc.narg('expiration_date'),
+    sqlc.arg('storage_loc'),
+    'count'
+  )
+RETURNING
+  *;
@@ -119,7 +119,11 @@ SET
     sqlc.narg ('pref_measure')::measure_type,
     pref_measure
   ),
-  profile_pic = COALESCE(sqlc.narg ('profile_pic'), profile_pic)
+  profile_pic = COALESCE(sqlc.narg ('profile_pic'), profile_pic),
+  merge_duplicates = COALESCE(
+    sqlc.narg ('merge_duplicates'),
+    merge_duplicates
+  )
 WHERE
   id = sqlc.arg ('id')
 RETURNING
@@ -170,7 +174,16 @@ INSERT INTO
     ingredient_id,
     quantity,
     price,
-    expiration_date
+    expiration_date,
+    initial_quantity,
+    location_id,
+    entered_unit,
+    brand,
+    store,
+    purchase_date,
+    note,
+    tags,
+    storage_loc
   )
 VALUES
   (
@@ -178,11 +191,21 @@ VALUES
     sqlc.arg ('ingredient_id'),
     sqlc.arg ('quantity'),
     sqlc.arg ('price'),
-    sqlc.narg ('expiration_date')
+    sqlc.narg ('expiration_date'),
+    sqlc.arg ('quantity'),
+    sqlc.narg ('location_id'),
+    sqlc.narg ('entered_unit'),
+    sqlc.narg ('brand'),
+    sqlc.narg ('store'),
+    sqlc.narg ('purchase_date'),
+    sqlc.narg ('note'),
+    COALESCE(sqlc.narg ('tags')::text[], '{}'),
+    sqlc.narg ('storage_loc')
   )
 RETURNING
   *;
 
+-- only touches entries owned by user_id, so other users' ids return no rows
 -- name: UpdateUserItemEntry :one
 UPDATE
   UserItemEntries
@@ -193,17 +216,38 @@ SET
   last_modified = CURRENT_TIMESTAMP
 WHERE
   id = sqlc.arg ('id')
+  AND user_id = sqlc.arg ('user_id')
+  AND deleted = false
 RETURNING
   *;
 
--- name: DeleteUserItemEntry :exec
+-- only sets opened_at once, already opened entries return no rows
+-- name: OpenUserItemEntry :one
+UPDATE
+  UserItemEntries
+SET
+  opened_at = sqlc.arg ('opened_at'),
+  last_modified = CURRENT_TIMESTAMP
+WHERE
+  id = sqlc.arg ('id')
+  AND user_id = sqlc.arg ('user_id')
+  AND deleted = false
+  AND opened_at IS NULL
+RETURNING
+  *;
+
+-- name: DeleteUserItemEntry :one
 UPDATE
   UserItemEntries
 SET
   deleted = true,
   last_modified = CURRENT_TIMESTAMP
 WHERE
-  id = sqlc.arg ('id');
+  id = sqlc.arg ('id')
+  AND user_id = sqlc.arg ('user_id')
+  AND deleted = false
+RETURNING
+  *;
 
 -- name: GetUserItemEntriesSinceTime :many
 SELECT
@@ -223,7 +267,15 @@ INSERT INTO UserItemEntries (
   price,
   expiration_date,
   last_modified,
-  deleted
+  deleted,
+  initial_quantity,
+  entered_unit,
+  brand,
+  store,
+  purchase_date,
+  note,
+  tags,
+  opened_at
 ) VALUES (
   sqlc.arg('id'),
   sqlc.arg('user_id'),
@@ -232,7 +284,15 @@ INSERT INTO UserItemEntries (
   sqlc.arg('price'),
   sqlc.narg('expiration_date'),
   sqlc.arg('last_modified'),
-  sqlc.arg('deleted')
+  sqlc.arg('deleted'),
+  sqlc.arg('quantity'),
+  sqlc.narg('entered_unit'),
+  sqlc.narg('brand'),
+  sqlc.narg('store'),
+  sqlc.narg('purchase_date'),
+  sqlc.narg('note'),
+  COALESCE(sqlc.narg('tags')::text[], '{}'),
+  sqlc.narg('opened_at')
 )
 ON CONFLICT (id) DO UPDATE
 SET
@@ -242,9 +302,17 @@ SET
   price = EXCLUDED.price,
   expiration_date = EXCLUDED.expiration_date,
   last_modified = EXCLUDED.last_modified,
-  deleted = EXCLUDED.deleted
+  deleted = EXCLUDED.deleted,
+  entered_unit = EXCLUDED.entered_unit,
+  brand = EXCLUDED.brand,
+  store = EXCLUDED.store,
+  purchase_date = EXCLUDED.purchase_date,
+  note = EXCLUDED.note,
+  tags = EXCLUDED.tags,
+  opened_at = EXCLUDED.opened_at
 WHERE
-  EXCLUDED.last_modified > UserItemEntries.last_modified
+  UserItemEntries.user_id = EXCLUDED.user_id
+  AND EXCLUDED.last_modified > UserItemEntries.last_modified
 RETURNING *;
 
 -- returns user items in the rawest form
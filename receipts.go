@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"pantree/api/db"
+	"pantree/api/models"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// lowest similarity a receipt line needs to be matched automatically
+	receiptMatchThreshold = 0.5
+	// lowest similarity still offered as a candidate for review
+	receiptCandidateThreshold = 0.3
+	maxReceiptCandidates      = 3
+)
+
+type ReceiptMatch struct {
+	IngredientID uuid.UUID   `json:"ingredientId"`
+	Name         string      `json:"name"`
+	Unit         db.UnitType `json:"unit"`
+	Score        float64     `json:"score"`
+}
+
+type ReceiptDraftItem struct {
+	models.ReceiptLine
+	Match      *ReceiptMatch  `json:"match"`
+	Candidates []ReceiptMatch `json:"candidates"`
+	// quantity in the matched ingredient's ground truth unit, null when the
+	// receipt's unit does not fit the ingredient and needs to be filled in
+	BaseQuantity *decimal.Decimal `json:"baseQuantity"`
+}
+
+// scores an ingredient by its best matching name or alias
+func ingredientSimilarity(name string, ingredient db.Ingredient) float64 {
+	best := models.Similarity(name, ingredient.Name)
+	for _, alias := range ingredient.Aliases {
+		best = max(best, models.Similarity(name, alias))
+	}
+
+	return best
+}
+
+func matchReceiptLine(line models.ReceiptLine, ingredients []db.Ingredient) ReceiptDraftItem {
+	item := ReceiptDraftItem{ReceiptLine: line, Candidates: []ReceiptMatch{}}
+
+	for _, ingredient := range ingredients {
+		score := ingredientSimilarity(line.Name, ingredient)
+		if score < receiptCandidateThreshold {
+			continue
+		}
+
+		item.Candidates = append(item.Candidates, ReceiptMatch{
+			IngredientID: ingredient.ID,
+			Name:         ingredient.Name,
+			Unit:         ingredient.Unit,
+			Score:        score,
+		})
+	}
+
+	sort.SliceStable(item.Candidates, func(i, j int) bool {
+		return item.Candidates[i].Score > item.Candidates[j].Score
+	})
+
+	if len(item.Candidates) > maxReceiptCandidates {
+		item.Candidates = item.Candidates[:maxReceiptCandidates]
+	}
+
+	if len(item.Candidates) == 0 || item.Candidates[0].Score < receiptMatchThreshold {
+		return item
+	}
+
+	item.Match = &item.Candidates[0]
+
+	if line.Unit != "" {
+		quantity, err := toIngredientQuantity(line.Quantity, line.Unit, item.Match.Unit)
+		if err == nil {
+			item.BaseQuantity = &quantity
+		}
+	}
+
+	return item
+}
+
+/**
+ * /parseReceipt
+ */
+type ParseReceiptRequest struct {
+	Text string `json:"text" binding:"required"`
+}
+
+// reads receipt text into a draft the user reviews before confirming it
+func _handleParseReceipt(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request ParseReceiptRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	ingredients, err := queries.GetIngredients(c, userUuid)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get ingredients.")
+		return
+	}
+
+	lines := models.ParseReceipt(request.Text)
+
+	draft := make([]ReceiptDraftItem, len(lines))
+	for i, line := range lines {
+		draft[i] = matchReceiptLine(line, ingredients)
+	}
+
+	log.Printf("Parsed %d receipt lines for user %s\n", len(draft), userUuid)
+	c.JSON(200, draft)
+}
+
+/**
+ * /confirmReceipt
+ */
+type ConfirmReceiptItem struct {
+	IngredientID   uuid.UUID           `json:"ingredientId" binding:"required"`
+	Quantity       decimal.Decimal     `json:"quantity" binding:"required"`
+	Unit           models.QuantityUnit `json:"unit"`
+	Price          *decimal.Decimal    `json:"price"`
+	ExpirationDate *float64            `json:"expirationDate"`
+}
+
+type ConfirmReceiptRequest struct {
+	Items []ConfirmReceiptItem `json:"items" binding:"required,min=1,dive"`
+}
+
+// creates an entry for every reviewed receipt item, all or nothing
+func _handleConfirmReceipt(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request ConfirmReceiptRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+	source := changeSourceFromContext(c, userUuid)
+
+	created := make([]db.Useritementry, 0, len(request.Items))
+	for i, item := range request.Items {
+		ingredient, err := qtx.GetVisibleIngredient(ctx, db.GetVisibleIngredientParams{
+			ID:     item.IngredientID,
+			UserID: userUuid,
+		})
+
+		if err == pgx.ErrNoRows {
+			sendError(c, 404, fmt.Errorf("ingredient %s not found", item.IngredientID), fmt.Sprintf("Ingredient for item %d not found.", i+1))
+			return
+		}
+
+		if err != nil {
+			sendError(c, 500, err, "Could not get ingredient.")
+			return
+		}
+
+		quantity, err := toIngredientQuantity(item.Quantity, item.Unit, ingredient.Unit)
+
+		if err != nil {
+			sendError(c, 400, err, fmt.Sprintf("Invalid unit for item %d.", i+1))
+			return
+		}
+
+		if !quantity.IsPositive() {
+			sendError(c, 400, fmt.Errorf("quantity %s is not positive", quantity), fmt.Sprintf("Quantity for item %d must be positive.", i+1))
+			return
+		}
+
+		// prices must be positive, free items are stored without one
+		var price decimal.NullDecimal
+		if item.Price != nil && item.Price.IsPositive() {
+			price = decimal.NewNullDecimal(*item.Price)
+		}
+
+		entry, err := createOrMergeUserItem(ctx, qtx, source, db.CreateUserItemEntryParams{
+			UserID:         &userUuid,
+			IngredientID:   &ingredient.ID,
+			Quantity:       quantity,
+			Price:          price,
+			ExpirationDate: msToTime(item.ExpirationDate),
+		})
+
+		if err != nil {
+			sendError(c, 500, err, "Could not create user item.")
+			return
+		}
+
+		created = append(created, entry)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	log.Printf("Created %d user items from a receipt for user %s\n", len(created), userUuid)
+	c.JSON(200, created)
+}
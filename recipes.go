@@ -40,6 +40,7 @@ type RecipeRequest struct {
 	ServingSize string             `json:"servingsize" binding:"required"`
 	ImagePath   string             `json:"imagepath"`
 	Ingredients []RecipeIngredient `json:"ingredients" binding:"required"`
+	Categories  []RecipeCategory   `json:"categories"`
 }
 
 type RecipeIngredient struct {
@@ -49,6 +50,15 @@ type RecipeIngredient struct {
 	AuthorMeasureType string `json:"authormeasuretype" binding:"required"`
 }
 
+// a generic requirement such as "200 g of cheese" that any ingredient in the
+// category's subtree can satisfy
+type RecipeCategory struct {
+	Category          string `json:"category" binding:"required"`
+	Quantity          string `json:"quantity" binding:"required"`
+	AuthorUnitType    string `json:"authorunittype" binding:"required"`
+	AuthorMeasureType string `json:"authormeasuretype" binding:"required"`
+}
+
 func createRecipe(c *gin.Context) {
 	var request RecipeRequest
 
@@ -143,6 +153,33 @@ func createRecipe(c *gin.Context) {
 		}
 	}
 
+	for _, category := range request.Categories {
+
+		requirement := db.CreateRecipeCategoryRequirementParams{
+			RecipeID:          createdRecipe.ID,
+			AuthorUnitType:    db.UnitType(category.AuthorUnitType),
+			AuthorMeasureType: db.MeasureType(category.AuthorMeasureType),
+		}
+
+		requirement.CategoryID, err = uuid.Parse(category.Category)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, err, "Invalid category UUID")
+			return
+		}
+
+		requirement.Quantity, err = decimal.NewFromString(category.Quantity)
+		if err != nil {
+			sendError(c, http.StatusBadRequest, err, "Invalid Quantity")
+			return
+		}
+
+		_, err = qtx.CreateRecipeCategoryRequirement(ctx, requirement)
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, err, "Could not insert category")
+			return
+		}
+	}
+
 	if err := tx.Commit(ctx); err != nil {
 		sendError(c, http.StatusInternalServerError, err, "Transaction failed")
 		return
@@ -249,6 +286,73 @@ func getFavorites(c *gin.Context) {
 	c.IndentedJSON(http.StatusOK, favorites)
 }
 
+// compares a recipe's requirements against the user's pantry
+func getRecipePantryMatch(c *gin.Context) {
+	recipeId, err := uuid.Parse(c.Query("id"))
+
+	if err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid recipe id")
+		return
+	}
+
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		log.Println("Unable to get user UUID: \n", err)
+		return
+	}
+
+	requirements, err := queries.GetRecipePantryMatch(c, db.GetRecipePantryMatchParams{
+		UserID:   userUuid,
+		RecipeID: recipeId,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not match recipe against pantry")
+		return
+	}
+
+	if len(requirements) == 0 {
+		requirements = []db.GetRecipePantryMatchRow{}
+	}
+
+	satisfied := true
+	for _, requirement := range requirements {
+		if requirement.AvailableQuantity.LessThan(requirement.RequiredQuantity) {
+			satisfied = false
+			break
+		}
+	}
+
+	c.IndentedJSON(http.StatusOK, gin.H{
+		"satisfied":    satisfied,
+		"requirements": requirements,
+	})
+}
+
+// "what can I eat tonight": recipes fully covered by the user's pantry
+func getMakeableRecipes(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		log.Println("Unable to get user UUID: \n", err)
+		return
+	}
+
+	recipes, err := queries.GetMakeableRecipes(c, userUuid)
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not fetch makeable recipes")
+		return
+	}
+
+	if len(recipes) == 0 {
+		recipes = []db.GetMakeableRecipesRow{}
+	}
+
+	c.IndentedJSON(http.StatusOK, recipes)
+}
+
 func registerRecipeRoutes(router *gin.RouterGroup) {
 	router.GET("/get", getRecipes)
 	router.POST("/create", createRecipe)
@@ -256,4 +360,6 @@ func registerRecipeRoutes(router *gin.RouterGroup) {
 	router.POST("/favorite", favoriteRecipe)
 	router.POST("/unfavorite", unfavoriteRecipe)
 	router.GET("/getfavorites", getFavorites)
+	router.GET("/pantryMatch", getRecipePantryMatch)
+	router.GET("/makeable", getMakeableRecipes)
 }
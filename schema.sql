@@ -18,6 +18,19 @@ CREATE TYPE GROC_TYPE AS ENUM(
   'alcohol'
 );
 
+CREATE TYPE VISIBILITY_TYPE AS ENUM('global', 'private', 'household');
+
+CREATE TYPE PROMOTION_STATUS AS ENUM('pending', 'approved', 'rejected');
+
+CREATE TYPE ENTRY_ACTION AS ENUM('create', 'update', 'delete', 'restore');
+
+CREATE TYPE DISPOSAL_OUTCOME AS ENUM(
+  'consumed',
+  'discarded_expired',
+  'discarded_spoiled',
+  'donated'
+);
+
 -- users
 CREATE TABLE
   Users (
@@ -27,7 +40,26 @@ CREATE TABLE
     date_joined DATE NOT NULL,
     pref_measure MEASURE_TYPE NOT NULL DEFAULT 'metric',
     last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    profile_pic TEXT
+    profile_pic TEXT,
+    is_admin BOOLEAN NOT NULL DEFAULT false,
+    -- combine new entries with matching existing ones instead of adding rows
+    merge_duplicates BOOLEAN NOT NULL DEFAULT false
+  );
+
+-- households share private ingredients between their members
+CREATE TABLE
+  Households (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    creator_id UUID REFERENCES Users (id) ON DELETE SET NULL,
+    name TEXT NOT NULL,
+    date_created DATE NOT NULL
+  );
+
+CREATE TABLE
+  HouseholdMembers (
+    household_id UUID REFERENCES Households (id) ON DELETE CASCADE,
+    user_id UUID REFERENCES Users (id) ON DELETE CASCADE,
+    PRIMARY KEY (household_id, user_id)
   );
 
 -- recipes
@@ -64,6 +96,15 @@ CREATE TABLE
     PRIMARY KEY (recipe_id, ingredient_id)
   );
 
+-- ingredient taxonomy, e.g. dairy > cheese > cheddar
+CREATE TABLE
+  IngredientCategories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    parent_id UUID REFERENCES IngredientCategories (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+  );
+
 -- all ingredients
 CREATE TABLE
   Ingredients (
@@ -74,9 +115,63 @@ CREATE TABLE
     storage_loc LOC_TYPE NOT NULL,
     ingredient_type GROC_TYPE NOT NULL,
     image_path TEXT,
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted BOOLEAN NOT NULL DEFAULT false,
+    aliases TEXT[] NOT NULL DEFAULT '{}',
+    shelf_life_days INTEGER CHECK (shelf_life_days > 0),
+    -- how long the ingredient keeps once opened, regardless of its printed date
+    opened_shelf_life_days INTEGER CHECK (opened_shelf_life_days > 0),
+    visibility VISIBILITY_TYPE NOT NULL DEFAULT 'private',
+    household_id UUID REFERENCES Households (id) ON DELETE SET NULL,
+    category_id UUID REFERENCES IngredientCategories (id) ON DELETE SET NULL
+  );
+
+-- global catalog ingredients are unique by normalized name
+CREATE UNIQUE INDEX ingredients_catalog_name_idx ON Ingredients (lower(btrim(name)))
+WHERE
+  visibility = 'global';
+
+-- requests from users to move a private ingredient into the global catalog
+CREATE TABLE
+  IngredientPromotions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    ingredient_id UUID NOT NULL REFERENCES Ingredients (id) ON DELETE CASCADE,
+    requester_id UUID REFERENCES Users (id) ON DELETE SET NULL,
+    reviewer_id UUID REFERENCES Users (id) ON DELETE SET NULL,
+    status PROMOTION_STATUS NOT NULL DEFAULT 'pending',
+    note TEXT,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
     last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
   );
 
+-- only one open request per ingredient
+CREATE UNIQUE INDEX ingredient_promotions_pending_idx ON IngredientPromotions (ingredient_id)
+WHERE
+  status = 'pending';
+
+-- recipe -> generic category link table, satisfied by any ingredient in the
+-- category or one of its descendants
+CREATE TABLE
+  RecipeCategoryRequirements (
+    recipe_id UUID REFERENCES Recipes (id) ON DELETE CASCADE,
+    category_id UUID REFERENCES IngredientCategories (id) ON DELETE CASCADE,
+    quantity NUMERIC NOT NULL,
+    author_unit_type UNIT_TYPE NOT NULL,
+    author_measure_type MEASURE_TYPE NOT NULL,
+    PRIMARY KEY (recipe_id, category_id)
+  );
+
+-- user defined storage locations, base_loc drives shelf life rules
+CREATE TABLE
+  StorageLocations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    user_id UUID NOT NULL REFERENCES Users (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    base_loc LOC_TYPE NOT NULL,
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, name)
+  );
+
 -- user inventories
 CREATE TABLE
   UserItemEntries (
@@ -87,7 +182,122 @@ CREATE TABLE
     price NUMERIC(1000, 2) CHECK (price > 0),
     expiration_date TIMESTAMP,
     last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
-    deleted BOOLEAN NOT NULL DEFAULT false
+    deleted BOOLEAN NOT NULL DEFAULT false,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- quantity when the entry was bought, price is for this amount
+    initial_quantity NUMERIC,
+    -- overrides the ingredient's storage_loc when set
+    location_id UUID REFERENCES StorageLocations (id) ON DELETE SET NULL,
+    -- unit the quantity was entered in, quantity itself is always stored in
+    -- the ingredient's ground truth unit
+    entered_unit TEXT,
+    brand TEXT,
+    store TEXT,
+    purchase_date TIMESTAMP,
+    note TEXT,
+    -- user defined, stored trimmed and lowercased
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    opened_at TIMESTAMP,
+    -- leftovers have a recipe instead of an ingredient, counted in portions
+    recipe_id UUID REFERENCES Recipes (id) ON DELETE SET NULL,
+    -- overrides the ingredient's storage_loc when set, e.g. for imported
+    -- entries and stocktake surpluses; leftovers have no ingredient and
+    -- always set it
+    storage_loc LOC_TYPE,
+    -- cost of one unit of what is left once other entries were merged in,
+    -- price and initial_quantity stay the record of this purchase
+    unit_cost NUMERIC
+  );
+
+CREATE INDEX user_item_entries_tags_idx ON UserItemEntries USING GIN (tags);
+
+-- minimum amount of an ingredient a user wants on hand, in its ground truth unit
+CREATE TABLE
+  ParLevels (
+    user_id UUID NOT NULL REFERENCES Users (id) ON DELETE CASCADE,
+    ingredient_id UUID NOT NULL REFERENCES Ingredients (id) ON DELETE CASCADE,
+    min_quantity NUMERIC NOT NULL CHECK (min_quantity > 0),
+    -- unit the level was set in, null for the ground truth unit
+    unit TEXT,
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, ingredient_id)
+  );
+
+-- shopping lists, item quantities are in the ingredient's ground truth unit
+CREATE TABLE
+  ShoppingLists (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    user_id UUID NOT NULL REFERENCES Users (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+  );
+
+CREATE TABLE
+  ShoppingListItems (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    list_id UUID NOT NULL REFERENCES ShoppingLists (id) ON DELETE CASCADE,
+    ingredient_id UUID NOT NULL REFERENCES Ingredients (id),
+    quantity NUMERIC NOT NULL CHECK (quantity > 0),
+    -- unit the quantity is shown in, null for the ground truth unit
+    unit TEXT,
+    note TEXT,
+    checked BOOLEAN NOT NULL DEFAULT false,
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+  );
+
+-- what happened to quantity taken out of an entry; cost is the share of the
+-- entry's price for that quantity
+CREATE TABLE
+  UserItemDisposals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    entry_id UUID NOT NULL REFERENCES UserItemEntries (id) ON DELETE CASCADE,
+    user_id UUID REFERENCES Users (id) ON DELETE CASCADE,
+    outcome DISPOSAL_OUTCOME NOT NULL,
+    quantity NUMERIC NOT NULL CHECK (quantity > 0),
+    cost NUMERIC,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+  );
+
+-- append-only log of every change to a user item entry
+CREATE TABLE
+  UserItemEntryHistory (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    entry_id UUID NOT NULL REFERENCES UserItemEntries (id) ON DELETE CASCADE,
+    user_id UUID REFERENCES Users (id) ON DELETE CASCADE,
+    actor_id UUID REFERENCES Users (id) ON DELETE SET NULL,
+    action ENTRY_ACTION NOT NULL,
+    -- snapshots of the entry row before and after the change
+    old_value JSONB,
+    new_value JSONB,
+    device_id TEXT,
+    source TEXT NOT NULL,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+  );
+
+CREATE INDEX user_item_entry_history_entry_idx ON UserItemEntryHistory (entry_id, date_created);
+
+-- a count of one storage location, either a user defined location or the
+-- entries without one in a base location
+CREATE TABLE
+  Stocktakes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid (),
+    user_id UUID NOT NULL REFERENCES Users (id) ON DELETE CASCADE,
+    location_id UUID REFERENCES StorageLocations (id) ON DELETE CASCADE,
+    storage_loc LOC_TYPE,
+    date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    applied_at TIMESTAMP,
+    CHECK ((location_id IS NULL) <> (storage_loc IS NULL))
+  );
+
+-- observed quantity of an ingredient, in its ground truth unit
+CREATE TABLE
+  StocktakeCounts (
+    stocktake_id UUID NOT NULL REFERENCES Stocktakes (id) ON DELETE CASCADE,
+    ingredient_id UUID NOT NULL REFERENCES Ingredients (id) ON DELETE CASCADE,
+    quantity NUMERIC NOT NULL CHECK (quantity >= 0),
+    last_modified TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (stocktake_id, ingredient_id)
   );
 
 -- recipe ingredients view
@@ -111,23 +321,45 @@ SELECT
   u.id AS user_id,
   u.email AS user_email,
   u.pref_measure AS user_measurement_system,
-  i.name AS ingredient_name,
+  i.id AS ingredient_id,
+  ui.recipe_id,
+  COALESCE(i.name, rec.name) AS ingredient_name,
   CAST(SUM(ui.quantity) AS NUMERIC) AS quantity,
-  MIN(ui.expiration_date) AS expiration_date,
-  i.unit,
-  i.storage_loc,
-  i.ingredient_type
+  -- effective expiry, the earlier of the printed date and the opened shelf life
+  MIN(
+    LEAST(
+      ui.expiration_date,
+      ui.opened_at + make_interval(days => i.opened_shelf_life_days)
+    )
+  ) AS expiration_date,
+  BOOL_OR(ui.opened_at IS NOT NULL) AS opened,
+  -- leftovers are counted in portions
+  COALESCE(i.unit, 'count_qtr') AS unit,
+  COALESCE(sl.base_loc, ui.storage_loc, i.storage_loc) AS storage_loc,
+  COALESCE(i.ingredient_type, 'prepared') AS ingredient_type,
+  ui.location_id,
+  sl.name AS location_name
 FROM
   Users u
   JOIN UserItemEntries ui ON u.id = ui.user_id
-  JOIN Ingredients i ON ui.ingredient_id = i.id
+  LEFT JOIN Ingredients i ON ui.ingredient_id = i.id
+  LEFT JOIN Recipes rec ON ui.recipe_id = rec.id
+  LEFT JOIN StorageLocations sl ON ui.location_id = sl.id
 WHERE
   ui.deleted = false
+  AND (
+    i.id IS NOT NULL
+    OR rec.id IS NOT NULL
+  )
 GROUP BY
   u.id,
   u.email,
   u.pref_measure,
-  i.name,
-  i.unit,
-  i.storage_loc,
-  i.ingredient_type;
\ No newline at end of file
+  i.id,
+  ui.recipe_id,
+  COALESCE(i.name, rec.name),
+  COALESCE(i.unit, 'count_qtr'),
+  COALESCE(sl.base_loc, ui.storage_loc, i.storage_loc),
+  COALESCE(i.ingredient_type, 'prepared'),
+  ui.location_id,
+  sl.name;
\ No newline at end of file
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"pantree/api/db"
+	"pantree/api/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type SeedResult struct {
+	Row   int        `json:"row"`
+	Name  string     `json:"name"`
+	ID    *uuid.UUID `json:"id,omitempty"`
+	Error string     `json:"error,omitempty"`
+}
+
+// normalizeIngredientName trims and collapses whitespace so "  Sea  Salt" and
+// "sea salt" land on the same catalog row (the index lowercases on its own)
+func normalizeIngredientName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+func validateSeedIngredient(row models.SeedIngredient) error {
+	if normalizeIngredientName(row.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !isValidUnitType(db.UnitType(row.Unit)) {
+		return fmt.Errorf("invalid unit %q", row.Unit)
+	}
+	if !isValidLocType(db.LocType(row.StorageLoc)) {
+		return fmt.Errorf("invalid storage location %q", row.StorageLoc)
+	}
+	if !isValidGrocType(db.GrocType(row.IngredientType)) {
+		return fmt.Errorf("invalid ingredient type %q", row.IngredientType)
+	}
+	if row.ShelfLifeDays != nil && *row.ShelfLifeDays <= 0 {
+		return fmt.Errorf("shelf life must be positive")
+	}
+	if row.OpenedShelfLifeDays != nil && *row.OpenedShelfLifeDays <= 0 {
+		return fmt.Errorf("opened shelf life must be positive")
+	}
+	return nil
+}
+
+// seedIngredients upserts every row on its own so one bad line does not stop
+// the rest of the catalog from loading
+func seedIngredients(ctx context.Context, rows []models.SeedRow) []SeedResult {
+	results := make([]SeedResult, 0, len(rows))
+	for _, seed := range rows {
+		row := seed.Ingredient
+		result := SeedResult{Row: seed.Line, Name: row.Name}
+
+		err := seed.Err
+		if err == nil {
+			err = validateSeedIngredient(row)
+		}
+
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		aliases := []string{}
+		for _, alias := range row.Aliases {
+			if alias = normalizeIngredientName(alias); alias != "" {
+				aliases = append(aliases, alias)
+			}
+		}
+
+		var shelfLife, openedShelfLife pgtype.Int4
+		if row.ShelfLifeDays != nil {
+			shelfLife = pgtype.Int4{Int32: *row.ShelfLifeDays, Valid: true}
+		}
+		if row.OpenedShelfLifeDays != nil {
+			openedShelfLife = pgtype.Int4{Int32: *row.OpenedShelfLifeDays, Valid: true}
+		}
+
+		ingredient, err := queries.UpsertCatalogIngredient(ctx, db.UpsertCatalogIngredientParams{
+			Name:                normalizeIngredientName(row.Name),
+			Unit:                db.UnitType(row.Unit),
+			StorageLoc:          db.LocType(row.StorageLoc),
+			IngredientType:      db.GrocType(row.IngredientType),
+			Aliases:             aliases,
+			ShelfLifeDays:       shelfLife,
+			OpenedShelfLifeDays: openedShelfLife,
+		})
+
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.ID = &ingredient.ID
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
+
+func countSeedFailures(results []SeedResult) int {
+	failed := 0
+	for _, result := range results {
+		if result.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+/**
+ * pantree-api seed ingredients <file.csv|file.json>
+ */
+func seedCommand(args []string) error {
+	if len(args) != 2 || args[0] != "ingredients" {
+		return fmt.Errorf("usage: pantree-api seed ingredients <file.csv|file.json>")
+	}
+
+	file, err := os.Open(args[1])
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	format := strings.TrimPrefix(strings.ToLower(path.Ext(args[1])), ".")
+	rows, err := models.ParseSeedFile(format, file)
+	if err != nil {
+		return err
+	}
+
+	results := seedIngredients(ctx, rows)
+	for _, result := range results {
+		if result.Error != "" {
+			log.Printf("row %d (%s): %s\n", result.Row, result.Name, result.Error)
+		}
+	}
+
+	log.Printf("Seeded %d of %d ingredients\n", len(results)-countSeedFailures(results), len(results))
+	return nil
+}
+
+/**
+ * /admin/seedIngredients
+ *
+ * accepts either a multipart "file" upload or a raw csv/json body
+ */
+func _handleSeedIngredients(c *gin.Context) {
+	var body io.Reader
+	var format string
+
+	if file, header, err := c.Request.FormFile("file"); err == nil {
+		defer file.Close()
+		body = file
+		format = strings.TrimPrefix(strings.ToLower(path.Ext(header.Filename)), ".")
+	} else {
+		body = c.Request.Body
+		format = "json"
+		if strings.Contains(c.ContentType(), "csv") {
+			format = "csv"
+		}
+	}
+
+	rows, err := models.ParseSeedFile(format, body)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, err, "Could not parse ingredient catalog.")
+		return
+	}
+
+	log.Printf("Seeding %d ingredients\n", len(rows))
+	results := seedIngredients(c, rows)
+	failed := countSeedFailures(results)
+
+	c.JSON(http.StatusOK, gin.H{
+		"seeded":  len(results) - failed,
+		"failed":  failed,
+		"results": results,
+	})
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"pantree/api/db"
+	"pantree/api/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type ShelfLifeSuggestion struct {
+	ExpirationDate *time.Time             `json:"expirationDate"`
+	ShelfLifeDays  *float64               `json:"shelfLifeDays"`
+	Source         models.ShelfLifeSource `json:"source"`
+	// finished entries the learned estimate is based on
+	Samples int32 `json:"samples"`
+}
+
+// suggestExpiration estimates when an entry of ingredient bought at start and
+// kept in storage expires, from how long the user's past entries lasted there
+// or else the catalog, which is scaled when storage is not the ingredient's
+// own storage_loc
+func suggestExpiration(ctx context.Context, qtx *db.Queries, userUuid uuid.UUID, ingredient db.Ingredient, storage db.LocType, start time.Time) (ShelfLifeSuggestion, error) {
+	learned, err := qtx.GetLearnedShelfLife(ctx, db.GetLearnedShelfLifeParams{
+		UserID:       &userUuid,
+		IngredientID: &ingredient.ID,
+		StorageLoc:   storage,
+	})
+
+	if err != nil {
+		return ShelfLifeSuggestion{}, err
+	}
+
+	var catalogDays *int32
+	if ingredient.ShelfLifeDays.Valid {
+		catalogDays = &ingredient.ShelfLifeDays.Int32
+	}
+
+	suggestion := ShelfLifeSuggestion{Samples: learned.Samples}
+
+	shelfLife, source, ok := models.EstimateShelfLife(learned.Samples, learned.MedianDays, catalogDays)
+	if !ok {
+		return suggestion, nil
+	}
+
+	expiration := start.Add(shelfLife)
+	if source == models.ShelfLifeFromCatalog {
+		expiration, err = models.AdjustExpiry(expiration, start, models.Category(ingredient.StorageLoc), models.Category(storage))
+
+		if err != nil {
+			return ShelfLifeSuggestion{}, err
+		}
+	}
+
+	days := expiration.Sub(start).Hours() / 24
+
+	suggestion.ExpirationDate = &expiration
+	suggestion.ShelfLifeDays = &days
+	suggestion.Source = source
+
+	return suggestion, nil
+}
+
+// entryStorageKind resolves the kind of storage an entry of ingredient is
+// kept in like the pantry view: the location's base, then the entry's own
+// storage_loc, then the ingredient's
+func entryStorageKind(ctx context.Context, qtx *db.Queries, userUuid uuid.UUID, ingredient db.Ingredient, locationId *uuid.UUID, storageLoc db.NullLocType) (db.LocType, error) {
+	if locationId != nil {
+		location, err := qtx.GetStorageLocation(ctx, db.GetStorageLocationParams{
+			ID:     *locationId,
+			UserID: userUuid,
+		})
+
+		if err != nil {
+			return "", err
+		}
+
+		return location.BaseLoc, nil
+	}
+
+	if storageLoc.Valid {
+		return storageLoc.LocType, nil
+	}
+
+	return ingredient.StorageLoc, nil
+}
+
+// defaultExpiration suggests an expiration date for an entry of ingredientId
+// created without one, counting from the purchase date when there is one and
+// taking into account where the entry is stored. Entries without an
+// ingredient get none.
+func defaultExpiration(ctx context.Context, qtx *db.Queries, userUuid uuid.UUID, ingredientId *uuid.UUID, locationId *uuid.UUID, storageLoc db.NullLocType, purchaseDate *time.Time) (*time.Time, error) {
+	if ingredientId == nil {
+		return nil, nil
+	}
+
+	ingredient, err := qtx.GetIngredient(ctx, *ingredientId)
+	if err != nil {
+		return nil, err
+	}
+
+	storage, err := entryStorageKind(ctx, qtx, userUuid, ingredient, locationId, storageLoc)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if purchaseDate != nil {
+		start = *purchaseDate
+	}
+
+	suggestion, err := suggestExpiration(ctx, qtx, userUuid, ingredient, storage, start)
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestion.ExpirationDate, nil
+}
+
+/**
+ * /suggestExpiration
+ */
+func _handleSuggestExpiration(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	ingredientId, err := uuid.Parse(c.Query("ingredientId"))
+
+	if err != nil {
+		sendError(c, 400, err, "Invalid ingredient id.")
+		return
+	}
+
+	ingredient, err := queries.GetVisibleIngredient(c, db.GetVisibleIngredientParams{
+		ID:     ingredientId,
+		UserID: userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, 404, err, "Ingredient not found.")
+		return
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get ingredient.")
+		return
+	}
+
+	// the ingredient's own storage unless a location or kind of storage is given
+	var locationId *uuid.UUID
+	if param := c.Query("locationId"); param != "" {
+		id, err := uuid.Parse(param)
+
+		if err != nil {
+			sendError(c, 400, err, "Invalid location id.")
+			return
+		}
+
+		if _, ok := _getOwnStorageLocation(c, userUuid, id); !ok {
+			return
+		}
+
+		locationId = &id
+	}
+
+	var storageLoc db.NullLocType
+	if loc := db.LocType(c.Query("storageLoc")); loc != "" {
+		if !isValidLocType(loc) {
+			sendError(c, 400, fmt.Errorf("invalid storage location %q", loc), "Invalid storage location.")
+			return
+		}
+		storageLoc = db.NullLocType{LocType: loc, Valid: true}
+	}
+
+	storage, err := entryStorageKind(c, queries, userUuid, ingredient, locationId, storageLoc)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get storage location.")
+		return
+	}
+
+	suggestion, err := suggestExpiration(c, queries, userUuid, ingredient, storage, time.Now())
+
+	if err != nil {
+		sendError(c, 500, err, "Could not estimate shelf life.")
+		return
+	}
+
+	c.JSON(200, suggestion)
+}
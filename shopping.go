@@ -0,0 +1,713 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"pantree/api/db"
+	"pantree/api/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/shopspring/decimal"
+)
+
+type ShoppingListItemResponse struct {
+	db.GetShoppingListItemsRow
+	DisplayQuantity decimal.Decimal     `json:"displayQuantity"`
+	DisplayUnit     models.QuantityUnit `json:"displayUnit"`
+}
+
+// items of one ingredient_type, listed in aisle order
+type ShoppingListGroup struct {
+	IngredientType db.GrocType                `json:"ingredientType"`
+	Items          []ShoppingListItemResponse `json:"items"`
+}
+
+type ShoppingListResponse struct {
+	db.Shoppinglist
+	Groups []ShoppingListGroup `json:"groups"`
+}
+
+// shows an item in the unit it was added with, falling back to the ground
+// truth unit
+func shoppingItemDisplay(quantity decimal.Decimal, ingredientUnit db.UnitType, unit pgtype.Text) (decimal.Decimal, models.QuantityUnit) {
+	if unit.Valid {
+		converted, err := models.FromBase(quantity, models.BaseUnit(ingredientUnit), models.QuantityUnit(unit.String))
+		if err == nil {
+			return converted, models.QuantityUnit(unit.String)
+		}
+		log.Println("Could not convert shopping list quantity for display:", err)
+	}
+
+	return quantity, models.QuantityUnit(ingredientUnit)
+}
+
+// loads one of the caller's shopping lists, responding 404 when it is missing
+// or belongs to someone else
+func _getOwnShoppingList(c *gin.Context, qtx *db.Queries, userUuid uuid.UUID, id uuid.UUID) (db.Shoppinglist, bool) {
+	list, err := qtx.GetShoppingList(c, db.GetShoppingListParams{
+		ID:     id,
+		UserID: userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, http.StatusNotFound, err, "Shopping list not found")
+		return list, false
+	}
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get shopping list")
+		return list, false
+	}
+
+	return list, true
+}
+
+/**
+ * /lists
+ */
+func getShoppingLists(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	lists, err := queries.GetShoppingLists(c, userUuid)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get shopping lists")
+		return
+	}
+
+	if lists == nil {
+		lists = []db.Shoppinglist{}
+	}
+
+	c.JSON(http.StatusOK, lists)
+}
+
+/**
+ * /list
+ */
+func getShoppingList(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	id, err := uuid.Parse(c.Query("id"))
+	if err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid shopping list id")
+		return
+	}
+
+	list, ok := _getOwnShoppingList(c, queries, userUuid, id)
+	if !ok {
+		return
+	}
+
+	items, err := queries.GetShoppingListItems(c, list.ID)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get shopping list items")
+		return
+	}
+
+	// items are already sorted by ingredient_type
+	groups := []ShoppingListGroup{}
+	for _, item := range items {
+		if len(groups) == 0 || groups[len(groups)-1].IngredientType != item.IngredientType {
+			groups = append(groups, ShoppingListGroup{IngredientType: item.IngredientType})
+		}
+
+		quantity, unit := shoppingItemDisplay(item.Quantity, item.IngredientUnit, item.Unit)
+		group := &groups[len(groups)-1]
+		group.Items = append(group.Items, ShoppingListItemResponse{
+			GetShoppingListItemsRow: item,
+			DisplayQuantity:         quantity,
+			DisplayUnit:             unit,
+		})
+	}
+
+	c.JSON(http.StatusOK, ShoppingListResponse{Shoppinglist: list, Groups: groups})
+}
+
+/**
+ * /newList
+ */
+type CreateShoppingListRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+func createShoppingList(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	var request CreateShoppingListRequest
+	if err := c.BindJSON(&request); err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid request body")
+		return
+	}
+
+	list, err := queries.CreateShoppingList(c, db.CreateShoppingListParams{
+		UserID: userUuid,
+		Name:   request.Name,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not create shopping list")
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+/**
+ * /deleteList
+ */
+type DeleteShoppingListRequest struct {
+	ID uuid.UUID `json:"id" binding:"required"`
+}
+
+func deleteShoppingList(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	var request DeleteShoppingListRequest
+	if err := c.BindJSON(&request); err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid request body")
+		return
+	}
+
+	list, err := queries.DeleteShoppingList(c, db.DeleteShoppingListParams{
+		ID:     request.ID,
+		UserID: userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, http.StatusNotFound, err, "Shopping list not found")
+		return
+	}
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not delete shopping list")
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+/**
+ * /addItem
+ */
+type AddShoppingListItemRequest struct {
+	ListID       uuid.UUID           `json:"listId" binding:"required"`
+	IngredientID uuid.UUID           `json:"ingredientId" binding:"required"`
+	Quantity     decimal.Decimal     `json:"quantity" binding:"required"`
+	Unit         models.QuantityUnit `json:"unit"`
+	Note         string              `json:"note"`
+}
+
+func addShoppingListItem(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	var request AddShoppingListItemRequest
+	if err := c.BindJSON(&request); err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid request body")
+		return
+	}
+
+	if !request.Quantity.IsPositive() {
+		sendError(c, http.StatusBadRequest, fmt.Errorf("quantity %s is not positive", request.Quantity), "Quantity must be positive")
+		return
+	}
+
+	list, ok := _getOwnShoppingList(c, queries, userUuid, request.ListID)
+	if !ok {
+		return
+	}
+
+	ingredient, err := queries.GetVisibleIngredient(c, db.GetVisibleIngredientParams{
+		ID:     request.IngredientID,
+		UserID: userUuid,
+	})
+	if err == pgx.ErrNoRows {
+		sendError(c, http.StatusNotFound, err, "Ingredient not found")
+		return
+	}
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get ingredient")
+		return
+	}
+
+	quantity, err := toIngredientQuantity(request.Quantity, request.Unit, ingredient.Unit)
+	if err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid unit")
+		return
+	}
+
+	var unit pgtype.Text
+	if request.Unit != "" {
+		unit = getPgtypeText(string(request.Unit))
+	}
+
+	var note pgtype.Text
+	if request.Note != "" {
+		note = getPgtypeText(request.Note)
+	}
+
+	item, err := queries.CreateShoppingListItem(c, db.CreateShoppingListItemParams{
+		ListID:       list.ID,
+		IngredientID: ingredient.ID,
+		Quantity:     quantity,
+		Unit:         unit,
+		Note:         note,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not add shopping list item")
+		return
+	}
+
+	if err := queries.TouchShoppingList(c, list.ID); err != nil {
+		log.Println("Could not update shopping list timestamp:", err)
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
+/**
+ * /updateItem
+ */
+type UpdateShoppingListItemRequest struct {
+	ID       uuid.UUID            `json:"id" binding:"required"`
+	Quantity *decimal.Decimal     `json:"quantity"`
+	Unit     *models.QuantityUnit `json:"unit"`
+	Note     *string              `json:"note"`
+	Checked  *bool                `json:"checked"`
+}
+
+func updateShoppingListItem(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	var request UpdateShoppingListItemRequest
+	if err := c.BindJSON(&request); err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid request body")
+		return
+	}
+
+	item, err := queries.GetShoppingListItem(c, db.GetShoppingListItemParams{
+		ID:     request.ID,
+		UserID: userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, http.StatusNotFound, err, "Shopping list item not found")
+		return
+	}
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get shopping list item")
+		return
+	}
+
+	// fields left out of the request keep their current values
+	params := db.UpdateShoppingListItemParams{
+		Quantity: item.Quantity,
+		Unit:     item.Unit,
+		Note:     item.Note,
+		Checked:  item.Checked,
+		ID:       item.ID,
+	}
+
+	if request.Unit != nil || request.Quantity != nil {
+		ingredient, err := queries.GetIngredient(c, item.IngredientID)
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, err, "Could not get ingredient")
+			return
+		}
+
+		if request.Unit != nil {
+			params.Unit = pgtype.Text{}
+			if *request.Unit != "" {
+				if base, ok := request.Unit.Base(); !ok || db.UnitType(base) != ingredient.Unit {
+					sendError(c, http.StatusBadRequest, fmt.Errorf("unit %s cannot be used for %s", *request.Unit, ingredient.Unit), "Invalid unit")
+					return
+				}
+				params.Unit = getPgtypeText(string(*request.Unit))
+			}
+		}
+
+		if request.Quantity != nil {
+			if !request.Quantity.IsPositive() {
+				sendError(c, http.StatusBadRequest, fmt.Errorf("quantity %s is not positive", request.Quantity), "Quantity must be positive")
+				return
+			}
+
+			// a new quantity is given in the item's (possibly new) unit
+			params.Quantity, err = toIngredientQuantity(*request.Quantity, models.QuantityUnit(params.Unit.String), ingredient.Unit)
+			if err != nil {
+				sendError(c, http.StatusBadRequest, err, "Invalid unit")
+				return
+			}
+		}
+	}
+
+	if request.Note != nil {
+		params.Note = pgtype.Text{}
+		if *request.Note != "" {
+			params.Note = getPgtypeText(*request.Note)
+		}
+	}
+
+	if request.Checked != nil {
+		params.Checked = *request.Checked
+	}
+
+	updated, err := queries.UpdateShoppingListItem(c, params)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not update shopping list item")
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+/**
+ * /removeItem
+ */
+type RemoveShoppingListItemRequest struct {
+	ID uuid.UUID `json:"id" binding:"required"`
+}
+
+func removeShoppingListItem(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	var request RemoveShoppingListItemRequest
+	if err := c.BindJSON(&request); err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid request body")
+		return
+	}
+
+	item, err := queries.GetShoppingListItem(c, db.GetShoppingListItemParams{
+		ID:     request.ID,
+		UserID: userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, http.StatusNotFound, err, "Shopping list item not found")
+		return
+	}
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get shopping list item")
+		return
+	}
+
+	if err := queries.DeleteShoppingListItem(c, item.ID); err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not remove shopping list item")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item removed"})
+}
+
+/**
+ * /generate
+ */
+type GenerateShoppingListRequest struct {
+	RecipeIDs []uuid.UUID `json:"recipeIds" binding:"required,min=1"`
+	// adds to an existing list, otherwise a new list called Name is created
+	ListID *uuid.UUID `json:"listId"`
+	Name   string     `json:"name"`
+}
+
+const defaultShoppingListName = "Shopping list"
+
+// adds whatever the recipes need that neither the pantry nor the list's
+// unchecked items already cover
+func generateShoppingList(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	var request GenerateShoppingListRequest
+	if err := c.BindJSON(&request); err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid request body")
+		return
+	}
+
+	user, err := queries.GetUser(c, db.GetUserParams{ID: &userUuid})
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get user")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Failed to start transaction")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+
+	var list db.Shoppinglist
+	if request.ListID != nil {
+		var ok bool
+		if list, ok = _getOwnShoppingList(c, qtx, userUuid, *request.ListID); !ok {
+			return
+		}
+	} else {
+		name := request.Name
+		if name == "" {
+			name = defaultShoppingListName
+		}
+
+		list, err = qtx.CreateShoppingList(ctx, db.CreateShoppingListParams{
+			UserID: userUuid,
+			Name:   name,
+		})
+
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, err, "Could not create shopping list")
+			return
+		}
+	}
+
+	shortfall, err := qtx.GetRecipeShortfall(ctx, db.GetRecipeShortfallParams{
+		RecipeIds: request.RecipeIDs,
+		UserID:    userUuid,
+	})
+
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not compare recipes with pantry")
+		return
+	}
+
+	existing, err := qtx.GetShoppingListItems(ctx, list.ID)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get shopping list items")
+		return
+	}
+
+	pending := map[uuid.UUID]decimal.Decimal{}
+	for _, item := range existing {
+		if !item.Checked {
+			pending[item.IngredientID] = pending[item.IngredientID].Add(item.Quantity)
+		}
+	}
+
+	// an ingredient can fall short both on its own and for a category
+	var order []uuid.UUID
+	totals := map[uuid.UUID]decimal.Decimal{}
+	for _, needed := range shortfall {
+		if _, ok := totals[needed.IngredientID]; !ok {
+			order = append(order, needed.IngredientID)
+		}
+		totals[needed.IngredientID] = totals[needed.IngredientID].Add(needed.Quantity)
+	}
+
+	added := 0
+	for _, ingredientId := range order {
+		needed := totals[ingredientId].Sub(pending[ingredientId])
+		if !needed.IsPositive() {
+			continue
+		}
+
+		ingredient, err := qtx.GetIngredient(ctx, ingredientId)
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, err, "Could not get ingredient")
+			return
+		}
+
+		// show generated items in the user's measurement system
+		var unit pgtype.Text
+		_, displayUnit, err := models.ToDisplay(needed, models.BaseUnit(ingredient.Unit), models.MeasureSystem(user.PrefMeasure))
+		if err == nil {
+			unit = getPgtypeText(string(displayUnit))
+		}
+
+		_, err = qtx.CreateShoppingListItem(ctx, db.CreateShoppingListItemParams{
+			ListID:       list.ID,
+			IngredientID: ingredient.ID,
+			Quantity:     needed,
+			Unit:         unit,
+		})
+
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, err, "Could not add shopping list item")
+			return
+		}
+
+		added++
+	}
+
+	if err := qtx.TouchShoppingList(ctx, list.ID); err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not update shopping list")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Transaction failed")
+		return
+	}
+
+	log.Printf("Generated %d shopping list items for user %s\n", added, userUuid)
+	c.JSON(http.StatusOK, list)
+}
+
+/**
+ * /checkout
+ */
+type CheckoutItem struct {
+	ID             uuid.UUID `json:"id" binding:"required"`
+	Price          *float64  `json:"price"`
+	ExpirationDate *float64  `json:"expirationDate"`
+}
+
+type CheckoutRequest struct {
+	ListID uuid.UUID      `json:"listId" binding:"required"`
+	Items  []CheckoutItem `json:"items"`
+}
+
+// turns every checked item on the list into a pantry entry and takes it off
+// the list; prices and expiration dates are optional per item
+func checkoutShoppingList(c *gin.Context) {
+	userUuid, err := getUserId(c)
+	if err != nil {
+		sendError(c, http.StatusUnauthorized, err, "Could not determine user")
+		return
+	}
+
+	var request CheckoutRequest
+	if err := c.BindJSON(&request); err != nil {
+		sendError(c, http.StatusBadRequest, err, "Invalid request body")
+		return
+	}
+
+	details := make(map[uuid.UUID]CheckoutItem, len(request.Items))
+	for _, item := range request.Items {
+		details[item.ID] = item
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Failed to start transaction")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+	source := changeSourceFromContext(c, userUuid)
+
+	list, ok := _getOwnShoppingList(c, qtx, userUuid, request.ListID)
+	if !ok {
+		return
+	}
+
+	checked, err := qtx.GetCheckedShoppingListItems(ctx, list.ID)
+	if err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not get checked items")
+		return
+	}
+
+	// details for anything else would be dropped, most likely a mistake
+	isChecked := make(map[uuid.UUID]bool, len(checked))
+	for _, item := range checked {
+		isChecked[item.ID] = true
+	}
+
+	for _, item := range request.Items {
+		if !isChecked[item.ID] {
+			sendError(c, http.StatusBadRequest, fmt.Errorf("item %s is not checked on list %s", item.ID, list.ID), "Checkout details can only be given for checked items")
+			return
+		}
+	}
+
+	created := make([]db.Useritementry, 0, len(checked))
+	for _, item := range checked {
+		detail := details[item.ID]
+
+		// prices must be positive, free items are stored without one
+		var price decimal.NullDecimal
+		if detail.Price != nil && *detail.Price > 0 {
+			price = decimal.NewNullDecimal(decimal.NewFromFloat(*detail.Price))
+		}
+
+		entry, err := createOrMergeUserItem(ctx, qtx, source, db.CreateUserItemEntryParams{
+			UserID:         &userUuid,
+			IngredientID:   &item.IngredientID,
+			Quantity:       item.Quantity,
+			Price:          price,
+			ExpirationDate: msToTime(detail.ExpirationDate),
+		})
+
+		if err != nil {
+			sendError(c, http.StatusInternalServerError, err, "Could not create user item")
+			return
+		}
+
+		created = append(created, entry)
+	}
+
+	if err := qtx.DeleteCheckedShoppingListItems(ctx, list.ID); err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not clear checked items")
+		return
+	}
+
+	if err := qtx.TouchShoppingList(ctx, list.ID); err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Could not update shopping list")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, http.StatusInternalServerError, err, "Transaction failed")
+		return
+	}
+
+	log.Printf("Checked out %d items from shopping list %s\n", len(created), list.ID)
+	c.JSON(http.StatusOK, created)
+}
+
+func registerShoppingRoutes(router *gin.RouterGroup) {
+	router.GET("/lists", getShoppingLists)
+	router.GET("/list", getShoppingList)
+	router.POST("/newList", createShoppingList)
+	router.POST("/deleteList", deleteShoppingList)
+	router.POST("/addItem", addShoppingListItem)
+	router.POST("/updateItem", updateShoppingListItem)
+	router.POST("/removeItem", removeShoppingListItem)
+	router.POST("/generate", generateShoppingList)
+	router.POST("/checkout", checkoutShoppingList)
+}
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"pantree/api/db"
+	"pantree/api/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/shopspring/decimal"
+)
+
+// gets a stocktake owned by the user, responding with an error and returning
+// false when it does not exist
+func _getOwnStocktake(c *gin.Context, userUuid uuid.UUID, id uuid.UUID) (db.Stocktake, bool) {
+	stocktake, err := queries.GetStocktake(c, db.GetStocktakeParams{
+		ID:     id,
+		UserID: userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, 404, err, "Stocktake not found.")
+		return stocktake, false
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get stocktake.")
+		return stocktake, false
+	}
+
+	return stocktake, true
+}
+
+/**
+ * /stocktakes
+ */
+func _handleGetStocktakes(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	stocktakes, err := queries.GetStocktakes(c, userUuid)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get stocktakes.")
+		return
+	}
+
+	if stocktakes == nil {
+		stocktakes = []db.Stocktake{}
+	}
+
+	c.JSON(200, stocktakes)
+}
+
+/**
+ * /startStocktake
+ */
+type StartStocktakeRequest struct {
+	// either a user defined location or a base location, in which case only
+	// entries without a user defined location are counted
+	LocationID *uuid.UUID  `json:"locationId"`
+	StorageLoc *db.LocType `json:"storageLoc"`
+}
+
+func _handleStartStocktake(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request StartStocktakeRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	if (request.LocationID == nil) == (request.StorageLoc == nil) {
+		sendError(c, 400, fmt.Errorf("stocktake needs exactly one location"), "Give either a locationId or a storageLoc.")
+		return
+	}
+
+	params := db.CreateStocktakeParams{
+		UserID:     userUuid,
+		LocationID: request.LocationID,
+	}
+
+	if request.LocationID != nil {
+		if _, ok := _getOwnStorageLocation(c, userUuid, *request.LocationID); !ok {
+			return
+		}
+	} else {
+		if !isValidLocType(*request.StorageLoc) {
+			sendError(c, 400, fmt.Errorf("invalid storage location %q", *request.StorageLoc), "Invalid storage location.")
+			return
+		}
+		params.StorageLoc = db.NullLocType{LocType: *request.StorageLoc, Valid: true}
+	}
+
+	stocktake, err := queries.CreateStocktake(c, params)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not start stocktake.")
+		return
+	}
+
+	c.JSON(200, stocktake)
+}
+
+/**
+ * /stocktakeCount
+ */
+type StocktakeCount struct {
+	IngredientID uuid.UUID       `json:"ingredientId" binding:"required"`
+	Quantity     decimal.Decimal `json:"quantity"`
+	// unit the quantity is given in, empty for the ingredient's ground truth unit
+	Unit models.QuantityUnit `json:"unit"`
+}
+
+type StocktakeCountRequest struct {
+	StocktakeID uuid.UUID        `json:"stocktakeId" binding:"required"`
+	Counts      []StocktakeCount `json:"counts" binding:"required,dive"`
+}
+
+func _handleStocktakeCount(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request StocktakeCountRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	stocktake, ok := _getOwnStocktake(c, userUuid, request.StocktakeID)
+	if !ok {
+		return
+	}
+
+	if stocktake.AppliedAt != nil {
+		sendError(c, 409, fmt.Errorf("stocktake %s was already applied", stocktake.ID), "Stocktake was already applied.")
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+
+	saved := make([]db.Stocktakecount, 0, len(request.Counts))
+	for _, count := range request.Counts {
+		ingredient, err := qtx.GetVisibleIngredient(ctx, db.GetVisibleIngredientParams{
+			ID:     count.IngredientID,
+			UserID: userUuid,
+		})
+
+		if err == pgx.ErrNoRows {
+			sendError(c, 404, err, "Ingredient not found.")
+			return
+		}
+
+		if err != nil {
+			sendError(c, 500, err, "Could not get ingredient.")
+			return
+		}
+
+		quantity, err := toIngredientQuantity(count.Quantity, count.Unit, ingredient.Unit)
+
+		if err != nil {
+			sendError(c, 400, err, "Invalid unit.")
+			return
+		}
+
+		if quantity.IsNegative() {
+			sendError(c, 400, fmt.Errorf("quantity %s is negative", quantity), "Quantity cannot be negative.")
+			return
+		}
+
+		row, err := qtx.SetStocktakeCount(ctx, db.SetStocktakeCountParams{
+			StocktakeID:  stocktake.ID,
+			IngredientID: ingredient.ID,
+			Quantity:     quantity,
+		})
+
+		if err != nil {
+			sendError(c, 500, err, "Could not save stocktake count.")
+			return
+		}
+
+		saved = append(saved, row)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	c.JSON(200, saved)
+}
+
+/**
+ * /stocktakeDiff
+ */
+func _handleGetStocktakeDiff(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	id, err := uuid.Parse(c.Query("id"))
+
+	if err != nil {
+		sendError(c, 400, err, "Invalid stocktake id.")
+		return
+	}
+
+	stocktake, ok := _getOwnStocktake(c, userUuid, id)
+	if !ok {
+		return
+	}
+
+	diff, err := queries.GetStocktakeDiff(c, stocktake.ID)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get stocktake diff.")
+		return
+	}
+
+	if diff == nil {
+		diff = []db.GetStocktakeDiffRow{}
+	}
+
+	c.JSON(200, diff)
+}
+
+/**
+ * /applyStocktake
+ */
+type ApplyStocktakeRequest struct {
+	StocktakeID uuid.UUID `json:"stocktakeId" binding:"required"`
+	// what happened to stock that was not found, required when anything is
+	// short
+	Outcome db.DisposalOutcome `json:"outcome"`
+}
+
+type ApplyStocktakeResponse struct {
+	Stocktake db.Stocktake             `json:"stocktake"`
+	Diff      []db.GetStocktakeDiffRow `json:"diff"`
+	Changed   []db.Useritementry       `json:"changed"`
+}
+
+// applies every counted difference: shortfalls come out of the covered entries
+// first-expiring first and are recorded as disposals, surpluses become new
+// entries in the location. Ingredients that were not counted are left alone.
+func _handleApplyStocktake(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request ApplyStocktakeRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	stocktake, ok := _getOwnStocktake(c, userUuid, request.StocktakeID)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+
+	stocktake, err = qtx.MarkStocktakeApplied(ctx, db.MarkStocktakeAppliedParams{
+		ID:     stocktake.ID,
+		UserID: userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, 409, err, "Stocktake was already applied.")
+		return
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not apply stocktake.")
+		return
+	}
+
+	// lock the entries before diffing so the diff matches what gets changed
+	entries, err := qtx.GetStocktakeEntries(ctx, stocktake.ID)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get stocktake entries.")
+		return
+	}
+
+	diff, err := qtx.GetStocktakeDiff(ctx, stocktake.ID)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get stocktake diff.")
+		return
+	}
+
+	byIngredient := map[uuid.UUID][]db.Useritementry{}
+	for _, entry := range entries {
+		byIngredient[*entry.IngredientID] = append(byIngredient[*entry.IngredientID], entry)
+	}
+
+	source := changeSourceFromContext(c, userUuid)
+	changed := []db.Useritementry{}
+
+	for _, row := range diff {
+		if !row.Difference.Valid || row.Difference.Decimal.IsZero() {
+			continue
+		}
+
+		if row.Difference.Decimal.IsPositive() {
+			params := db.CreateUserItemEntryParams{
+				UserID:       &userUuid,
+				IngredientID: &row.IngredientID,
+				Quantity:     row.Difference.Decimal,
+				LocationID:   stocktake.LocationID,
+			}
+
+			// surpluses counted in a base location stay there even when the
+			// ingredient usually lives somewhere else
+			if stocktake.StorageLoc.Valid {
+				ingredient, err := qtx.GetIngredient(ctx, row.IngredientID)
+
+				if err != nil {
+					sendError(c, 500, err, "Could not get ingredient.")
+					return
+				}
+
+				if ingredient.StorageLoc != stocktake.StorageLoc.LocType {
+					params.StorageLoc = stocktake.StorageLoc
+				}
+			}
+
+			created, err := createOrMergeUserItem(ctx, qtx, source, params)
+
+			if err != nil {
+				sendError(c, 500, err, "Could not create user item.")
+				return
+			}
+
+			changed = append(changed, created)
+			continue
+		}
+
+		if !isValidDisposalOutcome(request.Outcome) {
+			sendError(c, 400, fmt.Errorf("invalid outcome %q", request.Outcome), "Stocktakes with shortfalls need a valid outcome.")
+			return
+		}
+
+		remaining := row.Difference.Decimal.Neg()
+		for _, entry := range byIngredient[row.IngredientID] {
+			if !remaining.IsPositive() {
+				break
+			}
+
+			taken := decimal.Min(remaining, entry.Quantity)
+			remaining = remaining.Sub(taken)
+
+			updated, err := setUserItemQuantity(ctx, qtx, source, entry, entry.Quantity.Sub(taken))
+
+			if err != nil {
+				sendError(c, 500, err, "Could not update user item.")
+				return
+			}
+
+			if err := recordDisposal(ctx, qtx, entry, request.Outcome, taken); err != nil {
+				sendError(c, 500, err, "Could not record disposal.")
+				return
+			}
+
+			changed = append(changed, updated)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	log.Printf("Applied stocktake %s for user %s, %d entries changed\n", stocktake.ID, userUuid, len(changed))
+
+	if diff == nil {
+		diff = []db.GetStocktakeDiffRow{}
+	}
+
+	c.JSON(200, ApplyStocktakeResponse{
+		Stocktake: stocktake,
+		Diff:      diff,
+		Changed:   changed,
+	})
+}
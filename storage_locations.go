@@ -0,0 +1,306 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"pantree/api/db"
+	"pantree/api/models"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+/**
+ * /locations
+ */
+func _handleGetStorageLocations(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	locations, err := queries.GetStorageLocations(c, userUuid)
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get storage locations.")
+		return
+	}
+
+	if locations == nil {
+		locations = []db.Storagelocation{}
+	}
+
+	c.JSON(200, locations)
+}
+
+/**
+ * /newLocation
+ */
+type CreateStorageLocationRequest struct {
+	Name    string     `json:"name" binding:"required"`
+	BaseLoc db.LocType `json:"baseLoc" binding:"required"`
+}
+
+func _handleNewStorageLocation(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request CreateStorageLocationRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	if !isValidLocType(request.BaseLoc) {
+		sendError(c, 400, fmt.Errorf("invalid storage location %q", request.BaseLoc), "Invalid base storage location.")
+		return
+	}
+
+	location, err := queries.CreateStorageLocation(c, db.CreateStorageLocationParams{
+		UserID:  userUuid,
+		Name:    request.Name,
+		BaseLoc: request.BaseLoc,
+	})
+
+	if isUniqueViolation(err) {
+		sendError(c, 409, err, "A storage location with that name already exists.")
+		return
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not create storage location.")
+		return
+	}
+
+	c.JSON(200, location)
+}
+
+/**
+ * /deleteLocation
+ */
+type DeleteStorageLocationRequest struct {
+	ID uuid.UUID `json:"id" binding:"required"`
+}
+
+func _handleDeleteStorageLocation(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request DeleteStorageLocationRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	location, err := queries.DeleteStorageLocation(c, db.DeleteStorageLocationParams{
+		ID:     request.ID,
+		UserID: userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, 404, err, "Storage location not found.")
+		return
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not delete storage location.")
+		return
+	}
+
+	c.JSON(200, location)
+}
+
+// loads one of the caller's storage locations, responding 404 when it is
+// missing or belongs to someone else
+func _getOwnStorageLocation(c *gin.Context, userUuid uuid.UUID, id uuid.UUID) (db.Storagelocation, bool) {
+	location, err := queries.GetStorageLocation(c, db.GetStorageLocationParams{
+		ID:     id,
+		UserID: userUuid,
+	})
+
+	if err == pgx.ErrNoRows {
+		sendError(c, 404, err, "Storage location not found.")
+		return location, false
+	}
+
+	if err != nil {
+		sendError(c, 500, err, "Could not get storage location.")
+		return location, false
+	}
+
+	return location, true
+}
+
+/**
+ * /move
+ */
+type MoveUserItemsRequest struct {
+	IDs []uuid.UUID `json:"ids" binding:"required,min=1"`
+	// leaving this out moves the items back to their ingredient's storage_loc
+	LocationID *uuid.UUID `json:"locationId"`
+	// overrides the adjusted expiration for every moved item
+	ExpirationDate *float64 `json:"expirationDate"`
+}
+
+func _handleMoveUserItems(c *gin.Context) {
+	userUuid, err := getUserId(c)
+
+	if err != nil {
+		sendError(c, 401, err, "Could not determine user.")
+		return
+	}
+
+	var request MoveUserItemsRequest
+	if err := c.BindJSON(&request); err != nil {
+		log.Println("Invalid request body: \n", err)
+		sendError(c, 400, err, "Invalid request body.")
+		return
+	}
+
+	var target *db.Storagelocation
+	if request.LocationID != nil {
+		location, ok := _getOwnStorageLocation(c, userUuid, *request.LocationID)
+		if !ok {
+			return
+		}
+		target = &location
+	}
+
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction.")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+	source := changeSourceFromContext(c, userUuid)
+	now := time.Now()
+
+	moved := make([]db.Useritementry, 0, len(request.IDs))
+	for _, id := range request.IDs {
+		item, err := qtx.GetUserItemEntry(ctx, db.GetUserItemEntryParams{
+			ID:     id,
+			UserID: &userUuid,
+		})
+
+		if err == pgx.ErrNoRows || (err == nil && item.Deleted) {
+			sendError(c, 404, fmt.Errorf("user item %s not found", id), "User item not found.")
+			return
+		}
+
+		if err != nil {
+			sendError(c, 500, err, "Could not get user item.")
+			return
+		}
+
+		// the ingredient's storage_loc is the default for ingredient entries,
+		// leftovers have no ingredient and always carry their own
+		defaultLoc := item.StorageLoc.LocType
+		if item.IngredientID != nil {
+			ingredient, err := qtx.GetIngredient(ctx, *item.IngredientID)
+
+			if err != nil {
+				sendError(c, 500, err, "Could not get ingredient.")
+				return
+			}
+
+			defaultLoc = ingredient.StorageLoc
+		}
+
+		// resolved like the pantry view: the location's base, then the entry's
+		// own storage_loc, then the ingredient's
+		from := defaultLoc
+		if item.StorageLoc.Valid {
+			from = item.StorageLoc.LocType
+		}
+
+		if item.LocationID != nil {
+			current, err := qtx.GetStorageLocation(ctx, db.GetStorageLocationParams{
+				ID:     *item.LocationID,
+				UserID: userUuid,
+			})
+
+			if err != nil {
+				sendError(c, 500, err, "Could not get storage location.")
+				return
+			}
+
+			from = current.BaseLoc
+		}
+
+		// moving out of a location sends ingredient entries back to the
+		// ingredient's default, so their own storage_loc goes too
+		var to db.LocType
+		storageLoc := item.StorageLoc
+		switch {
+		case target != nil:
+			to = target.BaseLoc
+		case item.IngredientID != nil:
+			to = defaultLoc
+			storageLoc = db.NullLocType{}
+		default:
+			to = item.StorageLoc.LocType
+		}
+
+		expiration := item.ExpirationDate
+		if request.ExpirationDate != nil {
+			expiration = msToTime(request.ExpirationDate)
+		} else if expiration != nil {
+			adjusted, err := models.AdjustExpiry(*expiration, now, models.Category(from), models.Category(to))
+
+			if err != nil {
+				sendError(c, 500, err, "Could not adjust expiration.")
+				return
+			}
+
+			expiration = &adjusted
+		}
+
+		updated, err := qtx.MoveUserItemEntry(ctx, db.MoveUserItemEntryParams{
+			LocationID:     request.LocationID,
+			StorageLoc:     storageLoc,
+			ExpirationDate: expiration,
+			ID:             item.ID,
+			UserID:         &userUuid,
+		})
+
+		if err != nil {
+			sendError(c, 500, err, "Could not move user item.")
+			return
+		}
+
+		if err := recordEntryChange(ctx, qtx, source, db.EntryActionUpdate, &item, &updated); err != nil {
+			sendError(c, 500, err, "Could not record user item history.")
+			return
+		}
+
+		moved = append(moved, updated)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed.")
+		return
+	}
+
+	log.Printf("Moved %d user items for user %s\n", len(moved), userUuid)
+	c.JSON(200, moved)
+}
@@ -3,8 +3,10 @@ package main
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"hash/fnv"
 	"pantree/api/db"
+	"pantree/api/models"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -69,13 +71,21 @@ func _pullDbSyncState(c *gin.Context) (SyncState, error) {
 	return syncState, nil
 }
 
+// a device's copy of an entry; when Unit is set Quantity is given in it and
+// gets converted to the ingredient's ground truth unit
+type SyncItem struct {
+	db.Useritementry
+	Unit models.QuantityUnit `json:"unit"`
+	// what happened to quantity taken off the item, required when it gets
+	// deleted and consumed when left out otherwise
+	Outcome db.DisposalOutcome `json:"outcome"`
+}
+
 type SyncRequest struct {
-	Items        []db.Useritementry `json:"items" binding:"required"`
-	LastSyncTime time.Time          `json:"lastSyncTime" binding:"required"`
+	Items        []SyncItem `json:"items" binding:"required"`
+	LastSyncTime time.Time  `json:"lastSyncTime" binding:"required"`
 }
 
-// TODO: this may have a vulnerability if the Items request includes a UserID that does
-// not actually match the user making the request
 func sync(c *gin.Context) {
 	userUuid, err := getUserId(c)
 	if err != nil {
@@ -91,22 +101,187 @@ func sync(c *gin.Context) {
 		return
 	}
 
+	ctx := c.Request.Context()
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		sendError(c, 500, err, "Failed to start transaction")
+		return
+	}
+
+	defer tx.Rollback(ctx)
+
+	qtx := queries.WithTx(tx)
+	source := changeSourceFromContext(c, userUuid)
+
+	merge, err := userMergesDuplicates(ctx, qtx, userUuid)
+	if err != nil {
+		sendError(c, 500, err, "Unable to get user")
+		return
+	}
+
 	for _, item := range request.Items {
-		_, err := queries.UpsertUserItemEntry(c, db.UpsertUserItemEntryParams{
+		var old *db.Useritementry
+		existing, err := qtx.GetUserItemEntry(ctx, db.GetUserItemEntryParams{
+			ID:     item.ID,
+			UserID: &userUuid,
+		})
+
+		if err == nil {
+			old = &existing
+		} else if err != pgx.ErrNoRows {
+			sendError(c, 500, err, "Unable to get item")
+			return
+		}
+
+		deleting := old != nil && !old.Deleted && item.Deleted
+		if deleting && !isValidDisposalOutcome(item.Outcome) {
+			sendError(c, 400, fmt.Errorf("item %s is deleted without a valid outcome", item.ID), "Deleted items need a disposal outcome")
+			return
+		}
+
+		if !deleting && item.Outcome != "" && !isValidDisposalOutcome(item.Outcome) {
+			sendError(c, 400, fmt.Errorf("invalid outcome %q", item.Outcome), "Invalid disposal outcome")
+			return
+		}
+
+		// entries can only be pointed at ingredients the user can see, ones they
+		// already point at stay valid even after being deleted or unshared
+		if item.IngredientID != nil && (old == nil || !sameOptionalUuid(old.IngredientID, item.IngredientID)) {
+			_, err := qtx.GetVisibleIngredient(ctx, db.GetVisibleIngredientParams{
+				ID:     *item.IngredientID,
+				UserID: userUuid,
+			})
+
+			if err == pgx.ErrNoRows {
+				sendError(c, 400, err, "Unknown ingredient")
+				return
+			}
+
+			if err != nil {
+				sendError(c, 500, err, "Unable to get ingredient")
+				return
+			}
+		}
+
+		quantity, enteredUnit := item.Quantity, item.EnteredUnit
+		if item.Unit != "" {
+			if item.IngredientID == nil {
+				sendError(c, 400, fmt.Errorf("item %s has no ingredient", item.ID), "Items with a unit need an ingredient")
+				return
+			}
+
+			ingredient, err := qtx.GetIngredient(ctx, *item.IngredientID)
+			if err != nil {
+				sendError(c, 400, err, "Unknown ingredient")
+				return
+			}
+
+			quantity, err = toIngredientQuantity(item.Quantity, item.Unit, ingredient.Unit)
+			if err != nil {
+				sendError(c, 400, err, "Invalid unit")
+				return
+			}
+
+			enteredUnit = getPgtypeText(string(item.Unit))
+		}
+
+		// new entries get the same suggested expiration as ones made on the server,
+		// kept where the upsert leaves them: in their ingredient's storage
+		expiration := item.ExpirationDate
+		if old == nil && !item.Deleted && expiration == nil {
+			expiration, err = defaultExpiration(ctx, qtx, userUuid, item.IngredientID, nil, db.NullLocType{}, item.PurchaseDate)
+			if err != nil {
+				sendError(c, 500, err, "Unable to estimate shelf life")
+				return
+			}
+		}
+
+		upserted, err := qtx.UpsertUserItemEntry(ctx, db.UpsertUserItemEntryParams{
 			ID:             item.ID,
 			UserID:         &userUuid,
 			IngredientID:   item.IngredientID,
-			Quantity:       item.Quantity,
+			Quantity:       quantity,
 			Price:          item.Price,
-			ExpirationDate: item.ExpirationDate,
+			ExpirationDate: expiration,
 			LastModified:   item.LastModified,
 			Deleted:        item.Deleted,
+			EnteredUnit:    enteredUnit,
+			Brand:          item.Brand,
+			Store:          item.Store,
+			PurchaseDate:   item.PurchaseDate,
+			Note:           item.Note,
+			Tags:           normalizeTags(item.Tags),
+			OpenedAt:       item.OpenedAt,
 		})
 
-		if err != nil && err != pgx.ErrNoRows {
+		// the upsert never takes over another user's entry, so an id that was not
+		// found for this user but still conflicted belongs to someone else
+		if err == pgx.ErrNoRows && old == nil {
+			sendError(c, 403, fmt.Errorf("item %s belongs to another user", item.ID), "Item belongs to another user")
+			return
+		}
+
+		// stale items are skipped by the upsert and have nothing to record
+		if err == pgx.ErrNoRows {
+			continue
+		}
+
+		if err != nil {
 			sendError(c, 500, err, "Unable to upsert item")
 			return
 		}
+
+		err = recordEntryChange(ctx, qtx, source, entryActionFor(old, &upserted), old, &upserted)
+		if err != nil {
+			sendError(c, 500, err, "Unable to record item history")
+			return
+		}
+
+		if deleting {
+			if err := recordDisposal(ctx, qtx, *old, item.Outcome, old.Quantity); err != nil {
+				sendError(c, 500, err, "Unable to record disposal")
+				return
+			}
+		} else if old != nil && !old.Deleted && !upserted.Deleted {
+			// quantity used up on the device is consumption unless it says otherwise
+			outcome := item.Outcome
+			if outcome == "" {
+				outcome = db.DisposalOutcomeConsumed
+			}
+
+			if err := recordDisposal(ctx, qtx, *old, outcome, old.Quantity.Sub(upserted.Quantity)); err != nil {
+				sendError(c, 500, err, "Unable to record disposal")
+				return
+			}
+		}
+
+		// a new duplicate is folded into the existing entry and comes back to
+		// the device deleted, along with the entry it was merged into
+		if merge && old == nil && !upserted.Deleted {
+			target, err := findMergeTarget(ctx, qtx, userUuid, upserted)
+			if err != nil {
+				sendError(c, 500, err, "Unable to find duplicate items")
+				return
+			}
+
+			if target != nil {
+				if _, err := mergeUserItems(ctx, qtx, source, *target, []db.Useritementry{upserted}); err != nil {
+					sendError(c, 500, err, "Unable to merge item")
+					return
+				}
+
+				if _, err := removeMergedUserItem(ctx, qtx, source, upserted); err != nil {
+					sendError(c, 500, err, "Unable to remove merged item")
+					return
+				}
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		sendError(c, 500, err, "Transaction failed")
+		return
 	}
 
 	toSyncItems, err := queries.GetUserItemEntriesSinceTime(c, db.GetUserItemEntriesSinceTimeParams{
@@ -140,6 +315,8 @@ func sync(c *gin.Context) {
 		}
 	}
 
+	// every id comes from the user's own entries, which keep their ingredient
+	// even once it is deleted or no longer shared with them
 	ingredients, err := queries.GetIngredientsByIds(c, uuids)
 
 	if err != nil {
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -44,6 +45,19 @@ func createOrGetNewUser(ctx context.Context, email string) (*db.User, error) {
 	return &user, nil
 }
 
+// isAdmin reports whether the given user may manage data owned by others
+func isAdmin(ctx context.Context, userUuid uuid.UUID) (bool, error) {
+	user, err := queries.GetUser(ctx, db.GetUserParams{
+		ID: &userUuid,
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return user.IsAdmin, nil
+}
+
 func uploadUserImage(c *gin.Context) {
 	// read in image
 	file, header, err := c.Request.FormFile("image")
@@ -153,6 +167,8 @@ type UpdateUserRequest struct {
 	Email       string `form:"email" json:"email"`
 	Name        string `form:"name" json:"name"`
 	PrefMeasure string `form:"prefMeasure" json:"prefMeasure"`
+	// merge new pantry entries into matching existing ones
+	MergeDuplicates *bool `form:"mergeDuplicates" json:"mergeDuplicates"`
 }
 
 func handleUpdateMe(c *gin.Context) {
@@ -202,6 +218,13 @@ func handleUpdateMe(c *gin.Context) {
 		}
 	}
 
+	if request.MergeDuplicates != nil {
+		params.MergeDuplicates = pgtype.Bool{
+			Bool:  *request.MergeDuplicates,
+			Valid: true,
+		}
+	}
+
 	err = queries.UpdateUser(c, params)
 
 	if err != nil {